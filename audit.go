@@ -0,0 +1,161 @@
+package gormx
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Audit 为审计字段，由 gormx 根据请求上下文中的用户 ID（MetadataExtractor 提取的 UserId）自动填充。
+type Audit struct {
+	CreatedBy string `json:"created_by" gorm:"size:64;default:''" gormx:"created_by"`
+	UpdatedBy string `json:"updated_by" gorm:"size:64;default:''" gormx:"updated_by"`
+	DeletedBy string `json:"deleted_by" gorm:"size:64;default:''" gormx:"deleted_by"`
+}
+
+// TableAudit 为带审计字段的 uint64 主键基类（软删除时在同一条 UPDATE 中写入 DeletedBy）。
+type TableAudit struct {
+	Table
+	Audit
+}
+
+// TableUUIDAudit 为带审计字段的 UUIDv7 主键基类（软删除时在同一条 UPDATE 中写入 DeletedBy）。
+type TableUUIDAudit struct {
+	TableUUID
+	Audit
+}
+
+// auditPlugin 在创建、更新、软删除时填充审计字段。
+type auditPlugin struct {
+	extractor MetadataExtractor
+	// fields 缓存每个模型的审计字段，key 为 *schema.Schema。
+	fields sync.Map
+}
+
+// newAuditPlugin 构造审计插件，用户 ID 与日志使用同一个 MetadataExtractor。
+func newAuditPlugin(c *Conf) *auditPlugin {
+	return &auditPlugin{extractor: c.metadataExtractor()}
+}
+
+// Name 实现 gorm.Plugin。
+func (p *auditPlugin) Name() string {
+	return "gormx:audit"
+}
+
+// Initialize 实现 gorm.Plugin。
+func (p *auditPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(p.Name(), p.beforeCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(p.Name(), p.beforeUpdate); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register(p.Name(), p.beforeDelete)
+}
+
+// userId 返回当前请求的用户 ID 与模型的审计字段，无需处理时 ok 为 false。
+func (p *auditPlugin) userId(db *gorm.DB) (string, internal.AuditFields, bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.Context == nil {
+		return "", internal.AuditFields{}, false
+	}
+	fields := p.auditFields(stmt.Schema)
+	if fields.Empty() {
+		return "", fields, false
+	}
+	userId := p.extractor.Extract(stmt.Context).UserId
+	return userId, fields, userId != ""
+}
+
+// beforeCreate 为每条待创建记录填充 CreatedBy / UpdatedBy（已有值的不覆盖）。
+func (p *auditPlugin) beforeCreate(db *gorm.DB) {
+	userId, fields, ok := p.userId(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	fill := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct {
+			return
+		}
+		for _, field := range []*schema.Field{fields.CreatedBy, fields.UpdatedBy} {
+			if field == nil {
+				continue
+			}
+			if _, zero := field.ValueOf(stmt.Context, row); zero {
+				_ = field.Set(stmt.Context, row, userId)
+			}
+		}
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			fill(stmt.ReflectValue.Index(i))
+		}
+	case reflect.Struct:
+		fill(stmt.ReflectValue)
+	}
+}
+
+// beforeUpdate 将 UpdatedBy 加入更新列（UpdateColumn 等跳过钩子的更新与 UpdatedAt 一致，不填充）。
+func (p *auditPlugin) beforeUpdate(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.SkipHooks {
+		return
+	}
+	userId, fields, ok := p.userId(db)
+	if !ok || fields.UpdatedBy == nil {
+		return
+	}
+	if slices.Contains(stmt.Omits, fields.UpdatedBy.DBName) || slices.Contains(stmt.Omits, fields.UpdatedBy.Name) {
+		return
+	}
+
+	stmt.SetColumn(fields.UpdatedBy.DBName, userId, true)
+	// 显式 Select 部分列时，同样需要选中 UpdatedBy 才会写入。
+	if len(stmt.Selects) > 0 && !slices.Contains(stmt.Selects, "*") && !slices.Contains(stmt.Selects, fields.UpdatedBy.DBName) {
+		stmt.Selects = append(stmt.Selects, fields.UpdatedBy.DBName)
+	}
+}
+
+// beforeDelete 在软删除的 UPDATE 中追加 DeletedBy。
+// 软删除在构造 SET 子句时会整体替换已有赋值，因此这里写入 SET 子句的 AfterExpression，与其在同一条语句中生效；
+// 硬删除（Unscoped 或无软删除字段）不会构造 SET 子句，不受影响。
+func (p *auditPlugin) beforeDelete(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Unscoped {
+		return
+	}
+	userId, fields, ok := p.userId(db)
+	if !ok || fields.DeletedBy == nil || internal.SoftDeleteField(stmt.Schema) == nil {
+		return
+	}
+
+	c := stmt.Clauses["SET"]
+	c.Name = "SET"
+	c.AfterExpression = clause.Expr{SQL: ", ? = ?", Vars: []interface{}{clause.Column{Name: fields.DeletedBy.DBName}, userId}}
+	stmt.Clauses["SET"] = c
+
+	if stmt.ReflectValue.CanAddr() {
+		stmt.SetColumn(fields.DeletedBy.DBName, userId, true)
+	}
+}
+
+// auditFields 返回并缓存模型的审计字段。
+func (p *auditPlugin) auditFields(s *schema.Schema) internal.AuditFields {
+	if v, ok := p.fields.Load(s); ok {
+		return v.(internal.AuditFields)
+	}
+	fields := internal.ParseAuditFields(s)
+	p.fields.Store(s, fields)
+	return fields
+}
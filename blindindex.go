@@ -0,0 +1,187 @@
+package gormx
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// blindIndexPlugin 在创建与更新时为 gormx:"blind_index:<字段名>" 标签的列写入来源字段的盲索引。
+type blindIndexPlugin struct {
+	// fields 缓存每个模型的盲索引列，key 为 *schema.Schema。
+	fields sync.Map
+}
+
+// blindIndexFields 为缓存的盲索引列与解析错误。
+type blindIndexFields struct {
+	fields []internal.BlindIndexField
+	err    error
+}
+
+// Name 实现 gorm.Plugin。
+func (p *blindIndexPlugin) Name() string {
+	return "gormx:blind_index"
+}
+
+// Initialize 实现 gorm.Plugin。
+func (p *blindIndexPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(p.Name(), p.beforeCreate); err != nil {
+		return err
+	}
+	return cb.Update().Before("gorm:update").Register(p.Name(), p.beforeUpdate)
+}
+
+// indexFields 返回模型的盲索引列，无需处理时返回 nil。
+func (p *blindIndexPlugin) indexFields(db *gorm.DB) []internal.BlindIndexField {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return nil
+	}
+	v, ok := p.fields.Load(stmt.Schema)
+	if !ok {
+		fields, err := internal.BlindIndexFields(stmt.Schema)
+		v = blindIndexFields{fields: fields, err: err}
+		p.fields.Store(stmt.Schema, v)
+	}
+	cached := v.(blindIndexFields)
+	if cached.err != nil {
+		_ = db.AddError(cached.err)
+		return nil
+	}
+	return cached.fields
+}
+
+// index 计算来源列 source 的盲索引，来源值为零值时返回空字符串，失败时写入错误。
+func (p *blindIndexPlugin) index(db *gorm.DB, source *schema.Field, value interface{}, zero bool) (string, bool) {
+	if zero || value == nil {
+		return "", true
+	}
+	idx, err := BlindIndex(db.Statement.Schema.Table, source.DBName, value)
+	if err != nil {
+		_ = db.AddError(err)
+		return "", false
+	}
+	return idx, true
+}
+
+// beforeCreate 为每条待创建记录计算盲索引。
+func (p *blindIndexPlugin) beforeCreate(db *gorm.DB) {
+	fields := p.indexFields(db)
+	if len(fields) == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	fillMap := func(m map[string]interface{}) {
+		for _, f := range fields {
+			value, ok := m[f.Source.DBName]
+			if !ok {
+				value, ok = m[f.Source.Name]
+			}
+			if !ok {
+				continue
+			}
+			if idx, ok := p.index(db, f.Source, value, false); ok {
+				m[f.Field.DBName] = idx
+			}
+		}
+	}
+	fill := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct {
+			return
+		}
+		for _, f := range fields {
+			value, zero := sourceValue(stmt.Context, f.Source, row)
+			if idx, ok := p.index(db, f.Source, value, zero); ok {
+				_ = f.Field.Set(stmt.Context, row, idx)
+			}
+		}
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		fillMap(dest)
+		return
+	case *map[string]interface{}:
+		fillMap(*dest)
+		return
+	case []map[string]interface{}:
+		for _, m := range dest {
+			fillMap(m)
+		}
+		return
+	case *[]map[string]interface{}:
+		for _, m := range *dest {
+			fillMap(m)
+		}
+		return
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			fill(stmt.ReflectValue.Index(i))
+		}
+	case reflect.Struct:
+		fill(stmt.ReflectValue)
+	}
+}
+
+// beforeUpdate 在来源字段被更新时同时更新盲索引列。
+func (p *blindIndexPlugin) beforeUpdate(db *gorm.DB) {
+	fields := p.indexFields(db)
+	if len(fields) == 0 {
+		return
+	}
+
+	stmt := db.Statement
+	for _, f := range fields {
+		var (
+			value interface{}
+			zero  bool
+			ok    bool
+		)
+		switch dest := stmt.Dest.(type) {
+		case map[string]interface{}:
+			if value, ok = dest[f.Source.DBName]; !ok {
+				value, ok = dest[f.Source.Name]
+			}
+		default:
+			// 与 gorm 一致：未 Select 时只更新非零值字段。
+			row := reflect.Indirect(reflect.ValueOf(stmt.Dest))
+			if row.Kind() != reflect.Struct || row.Type() != stmt.Schema.ModelType {
+				continue
+			}
+			value, zero = sourceValue(stmt.Context, f.Source, row)
+			selectColumns, restricted := stmt.SelectAndOmitColumns(false, true)
+			selected, found := selectColumns[f.Source.DBName]
+			ok = (found && selected) || (!found && !restricted && !zero)
+		}
+		if !ok {
+			continue
+		}
+
+		idx, ok := p.index(db, f.Source, value, zero)
+		if !ok {
+			return
+		}
+		stmt.SetColumn(f.Field.DBName, idx, true)
+		// 显式 Select 部分列时，同样需要选中盲索引列才会写入。
+		if len(stmt.Selects) > 0 && !slices.Contains(stmt.Selects, "*") && !slices.Contains(stmt.Selects, f.Field.DBName) {
+			stmt.Selects = append(stmt.Selects, f.Field.DBName)
+		}
+	}
+}
+
+// sourceValue 返回来源字段的值；Encrypted 字段的 ValueOf 返回序列化器包装，需直接读取字段。
+func sourceValue(ctx context.Context, source *schema.Field, row reflect.Value) (interface{}, bool) {
+	v := source.ReflectValueOf(ctx, row)
+	return v.Interface(), v.IsZero()
+}
@@ -0,0 +1,210 @@
+package gormx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrBudgetExceeded 表示请求的查询预算已耗尽，可通过 errors.Is 判断。
+var ErrBudgetExceeded = errors.New("gormx: query budget exceeded")
+
+// BudgetMode 为查询预算超限时的处理方式。
+type BudgetMode uint32
+
+const (
+	// BudgetModeLog 超限时只输出 warn 日志，语句照常执行。
+	BudgetModeLog BudgetMode = iota
+	// BudgetModeAbort 超限后的语句不再执行，直接返回 *BudgetExceededError。
+	BudgetModeAbort
+)
+
+const (
+	// BudgetLimitStatements 表示语句条数超限。
+	BudgetLimitStatements = "statements"
+	// BudgetLimitDuration 表示累计 DB 耗时超限。
+	BudgetLimitDuration = "duration"
+	// BudgetLimitRows 表示累计行数（gorm 的 RowsAffected）超限。
+	BudgetLimitRows = "rows"
+)
+
+// Budget 为单个请求的查询预算，为 0 的项不限制。
+type Budget struct {
+	// MaxStatements 为最多执行的语句条数。
+	MaxStatements int64
+	// MaxDuration 为累计 DB 耗时上限。
+	MaxDuration time.Duration
+	// MaxRows 为累计行数上限，按每条语句的 RowsAffected 计：查询为扫描到目标中的行数，
+	// 写入为影响的行数，Count 计 1 行；db.Row()/db.Rows() 不计入（gorm 不统计其行数），
+	// 数据库扫描但被过滤掉的行同样不计入。
+	MaxRows int64
+	// Mode 为超限时的处理方式。
+	Mode BudgetMode
+}
+
+// BudgetUsage 为请求已消耗的预算。
+type BudgetUsage struct {
+	Statements int64
+	Duration   time.Duration
+	// Rows 为累计的 RowsAffected，计数方式见 Budget.MaxRows。
+	Rows int64
+}
+
+// BudgetExceededError 为超出查询预算时返回的错误。
+type BudgetExceededError struct {
+	// Limit 为超限的项（statements/duration/rows）。
+	Limit string
+	// Budget 为请求挂载的预算。
+	Budget Budget
+	// Usage 为超限时已消耗的预算。
+	Usage BudgetUsage
+}
+
+// Error 实现 error。
+func (e *BudgetExceededError) Error() string {
+	switch e.Limit {
+	case BudgetLimitStatements:
+		return fmt.Sprintf("%s: %d statements, limit %d", ErrBudgetExceeded, e.Usage.Statements, e.Budget.MaxStatements)
+	case BudgetLimitDuration:
+		return fmt.Sprintf("%s: %v db time, limit %v", ErrBudgetExceeded, e.Usage.Duration, e.Budget.MaxDuration)
+	default:
+		return fmt.Sprintf("%s: %d rows, limit %d", ErrBudgetExceeded, e.Usage.Rows, e.Budget.MaxRows)
+	}
+}
+
+// Unwrap 使 errors.Is(err, ErrBudgetExceeded) 成立。
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// budgetKey 为 queryBudget 在 context 中的 key。
+type budgetKey struct{}
+
+// WithBudget 为请求上下文挂载查询预算，需配合 db.WithContext(ctx) 使用。
+func WithBudget(ctx context.Context, budget Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &queryBudget{budget: budget, warned: make(map[string]bool)})
+}
+
+// BudgetUsageFromContext 返回请求已消耗的预算，未挂载预算时 ok 为 false。
+func BudgetUsageFromContext(ctx context.Context) (usage BudgetUsage, ok bool) {
+	b, ok := ctx.Value(budgetKey{}).(*queryBudget)
+	if !ok {
+		return BudgetUsage{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usage, true
+}
+
+// queryBudget 为挂载在 context 上的预算与消耗，可被多个协程共享。
+type queryBudget struct {
+	budget Budget
+
+	mu     sync.Mutex
+	usage  BudgetUsage
+	warned map[string]bool
+}
+
+// exceeded 返回已超限的项，usage 为将要执行的语句计入后的消耗。
+func (b *queryBudget) exceeded(usage BudgetUsage) string {
+	switch {
+	case b.budget.MaxStatements > 0 && usage.Statements > b.budget.MaxStatements:
+		return BudgetLimitStatements
+	case b.budget.MaxDuration > 0 && usage.Duration > b.budget.MaxDuration:
+		return BudgetLimitDuration
+	case b.budget.MaxRows > 0 && usage.Rows > b.budget.MaxRows:
+		return BudgetLimitRows
+	default:
+		return ""
+	}
+}
+
+// budgetStartKey 为语句开始时间在 Statement 实例设置中的 key。
+const budgetStartKey = "gormx:budget_start"
+
+// budgetPlugin 在每条语句前后核算请求的查询预算。
+type budgetPlugin struct{}
+
+// Name 实现 gorm.Plugin。
+func (budgetPlugin) Name() string {
+	return "gormx:budget"
+}
+
+// Initialize 实现 gorm.Plugin。
+func (p budgetPlugin) Initialize(db *gorm.DB) error {
+	return registerAround(db, p.Name(), p.before, p.after)
+}
+
+// before 计入语句条数；Abort 模式下已超限时中止语句。
+func (budgetPlugin) before(db *gorm.DB) {
+	b := budgetFromStatement(db)
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.usage.Statements++
+	usage := b.usage
+	limit := b.exceeded(usage)
+	b.mu.Unlock()
+
+	if limit != "" && b.budget.Mode == BudgetModeAbort {
+		_ = db.AddError(&BudgetExceededError{Limit: limit, Budget: b.budget, Usage: usage})
+		return
+	}
+	db.InstanceSet(budgetStartKey, time.Now())
+	if limit != "" {
+		warnBudget(db, b, limit, usage)
+	}
+}
+
+// after 计入本条语句的耗时与 RowsAffected，Log 模式下超限时输出 warn 日志。
+func (budgetPlugin) after(db *gorm.DB) {
+	b := budgetFromStatement(db)
+	if b == nil {
+		return
+	}
+	start, ok := db.InstanceGet(budgetStartKey)
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	b.usage.Duration += time.Since(start.(time.Time))
+	if db.RowsAffected > 0 {
+		b.usage.Rows += db.RowsAffected
+	}
+	usage := b.usage
+	limit := b.exceeded(usage)
+	b.mu.Unlock()
+
+	if limit != "" {
+		warnBudget(db, b, limit, usage)
+	}
+}
+
+// warnBudget 每个超限项只输出一次 warn 日志。
+func warnBudget(db *gorm.DB, b *queryBudget, limit string, usage BudgetUsage) {
+	b.mu.Lock()
+	warned := b.warned[limit]
+	b.warned[limit] = true
+	b.mu.Unlock()
+	if warned {
+		return
+	}
+	err := &BudgetExceededError{Limit: limit, Budget: b.budget, Usage: usage}
+	db.Logger.Warn(db.Statement.Context, "%s", err.Error())
+}
+
+// budgetFromStatement 读取语句上下文中挂载的预算。
+func budgetFromStatement(db *gorm.DB) *queryBudget {
+	if db.Statement.Context == nil {
+		return nil
+	}
+	b, _ := db.Statement.Context.Value(budgetKey{}).(*queryBudget)
+	return b
+}
@@ -1,6 +1,16 @@
 package gormx
 
-import "github.com/fireflycore/go-utils/tlsx"
+import (
+	"github.com/fireflycore/go-utils/tlsx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Postgres 为 Conf.Type 中的 Postgres。
+	Postgres uint32 = 1
+	// Mysql 为 Conf.Type 中的 MySQL。
+	Mysql uint32 = 4
+)
 
 // Conf 为 gorm 初始化所需的配置项集合。
 type Conf struct {
@@ -51,10 +61,51 @@ type Conf struct {
 	// Logger 为 true 时启用 gorm logger，并可通过 WithLoggerConsole 控制输出。
 	Logger bool `json:"logger"`
 
+	// 是否对慢 SQL 自动执行 EXPLAIN（仅 SELECT，在独立连接上执行，结果附加到 warn 日志）
+	// ExplainSlowQuery 为 true 时 MySQL 执行 EXPLAIN，Postgres 执行 EXPLAIN (FORMAT JSON)。
+	ExplainSlowQuery bool `json:"explain_slow_query"`
+	// 同一查询形状两次 EXPLAIN 的最小间隔（单位：秒，默认60秒）
+	// ExplainInterval 按 SQL 指纹限流，<=0 时使用默认值。
+	ExplainInterval int `json:"explain_interval"`
+
+	// 链路追踪配置（零值表示开启追踪并记录完整 SQL）
+	// Tracing 控制 otelgorm 插件的开关、SQL 脱敏、静态属性与 SELECT 采样。
+	Tracing TracingConf `json:"tracing"`
+
+	// N+1 查询检测（为空表示不启用，开发环境建议开启，生产环境建议配合采样率使用）
+	// NPlusOne 需配合 WithQueryTracker 为每个请求挂载统计上下文。
+	NPlusOne *NPlusOneConf `json:"n_plus_one"`
+
+	// sqlcommenter 配置（为空表示不启用，不能与 PrepareStmt 同时开启）
+	// SqlCommenter 在每条 SQL 末尾追加 traceparent、应用名、调用方与 gRPC 方法，便于在数据库侧关联链路。
+	SqlCommenter *SqlCommenterConf `json:"sql_commenter"`
+
+	// 事务级追踪配置（为空表示不启用）
+	// Transaction 记录事务的 begin/commit/rollback、总耗时与语句条数，超过慢事务阈值时输出 warn 日志，并为事务创建 Span。
+	Transaction *TransactionConf `json:"transaction"`
+
+	// 连接与事务泄漏检测配置（为空表示不启用，建议仅在调试/预发环境开启）
+	// LeakDetector 记录每个事务的开启栈与开始时间，长事务、未结束事务与连接池等待时输出 warn 日志。
+	LeakDetector *LeakDetectorConf `json:"leak_detector"`
+
+	// Postgres 行级安全策略配置（为空表示不启用，仅支持 Postgres）
+	// RowLevelSecurity 将请求上下文中的租户 ID 与用户 ID 写入会话设置（app.tenant_id / app.user_id），供 RLS 策略读取。
+	RowLevelSecurity *RowLevelSecurityConf `json:"row_level_security"`
+
 	// autoMigrate 控制 NewMysql/NewPostgres 是否执行 AutoMigrate。
 	autoMigrate bool
 	// loggerConsole 控制是否输出到控制台。
 	loggerConsole bool
+	// sinks 为结构化日志输出目标，为空时默认上报到 OTel。
+	sinks []Sink
+	// disableCaller 为 true 时日志不定位调用方（Path 为空）。
+	disableCaller bool
+	// extractor 从请求上下文中提取用户、应用、租户等信息，为空时读取 gRPC metadata。
+	extractor MetadataExtractor
+	// tracerProvider 为链路追踪使用的 TracerProvider，为空时使用全局 TracerProvider。
+	tracerProvider trace.TracerProvider
+	// idGenerators 为 gormx:"id:<name>" 标签使用的 ID 生成器（内置 uuidv7 / ulid）。
+	idGenerators map[string]IDGenerator
 }
 
 // WithLoggerConsole 设置是否将 SQL 日志输出到控制台。
@@ -66,3 +117,26 @@ func (c *Conf) WithLoggerConsole(state bool) {
 func (c *Conf) WithAutoMigrate(state bool) {
 	c.autoMigrate = state
 }
+
+// WithSinks 设置结构化日志的输出目标，设置后不再默认上报 OTel（需要时显式加入 NewOTelSink）。
+func (c *Conf) WithSinks(sinks ...Sink) {
+	c.sinks = sinks
+}
+
+// WithLoggerCaller 设置日志是否定位调用方（默认开启，调用点解析结果会被缓存）。
+func (c *Conf) WithLoggerCaller(state bool) {
+	c.disableCaller = !state
+}
+
+// WithMetadataExtractor 设置从请求上下文中提取用户、应用、租户等信息的方式（默认读取 gRPC metadata）。
+func (c *Conf) WithMetadataExtractor(extractor MetadataExtractor) {
+	c.extractor = extractor
+}
+
+// metadataExtractor 返回生效的 MetadataExtractor。
+func (c *Conf) metadataExtractor() MetadataExtractor {
+	if c.extractor == nil {
+		return NewGRPCMetadataExtractor()
+	}
+	return c.extractor
+}
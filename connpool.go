@@ -0,0 +1,286 @@
+package gormx
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// queryRewriter 在 SQL 发往驱动前改写语句。
+type queryRewriter func(ctx context.Context, query string) string
+
+// txObserver 在事务开始时调用，返回的回调（可为 nil）在事务提交或回滚后调用一次。
+type txObserver func(tx *connTx) func(committed bool)
+
+// sessionSetup 在事务或语句所用的连接上执行初始化语句（如 set_config），local 为 true 时仅在当前事务内生效。
+type sessionSetup func(ctx context.Context, conn gorm.ConnPool, local bool) error
+
+// connPool 包装 gorm.ConnPool，为语句与事务提供统一的扩展点（仅在启用相关功能时挂载）。
+type connPool struct {
+	gorm.ConnPool
+	// rewriters 依次改写 SQL。
+	rewriters []queryRewriter
+	// observers 观察事务的开始与结束。
+	observers []txObserver
+	// txSetups 在事务开始后、返回给调用方之前执行。
+	txSetups []sessionSetup
+	// stmtSetups 非空时，事务外的语句在独占连接上先执行初始化再执行。
+	stmtSetups []sessionSetup
+}
+
+// wrapConnPool 为 db 挂载 connPool，重复调用时复用同一包装层。
+func wrapConnPool(db *gorm.DB) *connPool {
+	if pool, ok := db.ConnPool.(*connPool); ok {
+		return pool
+	}
+	pool := &connPool{ConnPool: db.ConnPool}
+	db.ConnPool = pool
+	db.Statement.ConnPool = pool
+	return pool
+}
+
+// pin 返回绑定到独占连接 conn 的包装层，沿用 SQL 改写、事务观察与事务初始化；
+// stmtSetups 在该连接上执行一次（会话级），之后的语句不再逐条初始化。
+func (p *connPool) pin(ctx context.Context, conn *sql.Conn) (*connPool, error) {
+	for _, setup := range p.stmtSetups {
+		if err := setup(ctx, conn, false); err != nil {
+			return nil, err
+		}
+	}
+	return &connPool{ConnPool: conn, rewriters: p.rewriters, observers: p.observers, txSetups: p.txSetups}, nil
+}
+
+// rewrite 依次应用所有 queryRewriter。
+func (p *connPool) rewrite(ctx context.Context, query string) string {
+	for _, rewriter := range p.rewriters {
+		query = rewriter(ctx, query)
+	}
+	return query
+}
+
+// PrepareContext 实现 gorm.ConnPool。
+func (p *connPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.ConnPool.PrepareContext(ctx, p.rewrite(ctx, query))
+}
+
+// ExecContext 实现 gorm.ConnPool。
+func (p *connPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if len(p.stmtSetups) != 0 {
+		conn, err := p.session(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return conn.ExecContext(ctx, p.rewrite(ctx, query), args...)
+	}
+	return p.ConnPool.ExecContext(ctx, p.rewrite(ctx, query), args...)
+}
+
+// QueryContext 实现 gorm.ConnPool。
+func (p *connPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if len(p.stmtSetups) != 0 {
+		conn, err := p.session(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := conn.QueryContext(ctx, p.rewrite(ctx, query), args...)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		// sql.Conn.Close 会等待 rows 关闭后才归还连接。
+		go conn.Close()
+		return rows, nil
+	}
+	return p.ConnPool.QueryContext(ctx, p.rewrite(ctx, query), args...)
+}
+
+// QueryRowContext 实现 gorm.ConnPool。
+func (p *connPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if len(p.stmtSetups) != 0 {
+		// sql.Row 无法携带错误，初始化失败时退回连接池执行（会话设置已被清空，由数据库策略拒绝）。
+		if conn, err := p.session(ctx); err == nil {
+			row := conn.QueryRowContext(ctx, p.rewrite(ctx, query), args...)
+			go conn.Close()
+			return row
+		}
+	}
+	return p.ConnPool.QueryRowContext(ctx, p.rewrite(ctx, query), args...)
+}
+
+// session 从连接池取出一个独占连接并依次执行 stmtSetups，调用方负责 Close。
+func (p *connPool) session(ctx context.Context) (*sql.Conn, error) {
+	sqlDB, err := p.GetDBConn()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, setup := range p.stmtSetups {
+		if err = setup(ctx, conn, false); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// GetDBConn 实现 gorm.GetDBConnector，保证 db.DB() 可用。
+func (p *connPool) GetDBConn() (*sql.DB, error) {
+	switch inner := p.ConnPool.(type) {
+	case *sql.DB:
+		return inner, nil
+	case gorm.GetDBConnector:
+		return inner.GetDBConn()
+	default:
+		return nil, gorm.ErrInvalidDB
+	}
+}
+
+// Ping 透传到底层连接池。
+func (p *connPool) Ping() error {
+	if pinger, ok := p.ConnPool.(interface{ Ping() error }); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
+
+// BeginTx 实现 gorm.ConnPoolBeginner，返回的事务同样经过包装。
+func (p *connPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		err = gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, setup := range p.txSetups {
+		if err = setup(ctx, tx, true); err != nil {
+			if committer, ok := tx.(gorm.TxCommitter); ok {
+				_ = committer.Rollback()
+			}
+			return nil, err
+		}
+	}
+
+	t := &connTx{ConnPool: tx, pool: p, ctx: ctx}
+	for _, observe := range p.observers {
+		if end := observe(t); end != nil {
+			t.onEnd = append(t.onEnd, end)
+		}
+	}
+	return t, nil
+}
+
+// connTx 包装事务连接，实现 gorm.Tx。
+type connTx struct {
+	gorm.ConnPool
+	pool *connPool
+	// ctx 为开启事务时的上下文。
+	ctx context.Context
+	// onEnd 为各 txObserver 返回的结束回调。
+	onEnd []func(committed bool)
+	// done 保证结束回调只执行一次。
+	done atomic.Bool
+	// statements 为事务内执行的语句条数。
+	statements atomic.Int64
+	// span 为事务级 Span（开启事务追踪时），事务内语句的 Span 作为其子 Span。
+	span trace.Span
+}
+
+// finish 在事务结束时依次调用结束回调。
+func (t *connTx) finish(committed bool) {
+	if !t.done.CompareAndSwap(false, true) {
+		return
+	}
+	for _, end := range t.onEnd {
+		end(committed)
+	}
+}
+
+// PrepareContext 实现 gorm.ConnPool。
+func (t *connTx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	t.statements.Add(1)
+	return t.ConnPool.PrepareContext(ctx, t.pool.rewrite(ctx, query))
+}
+
+// ExecContext 实现 gorm.ConnPool。
+func (t *connTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	t.statements.Add(1)
+	conn := t.ConnPool
+	// 与 gorm.SavePoint 一致：保存点语句不支持预处理，绕过 PreparedStmtTX 直接在事务上执行。
+	if prepared, ok := conn.(*gorm.PreparedStmtTX); ok && isSavePointStatement(query) {
+		conn = prepared.Tx
+	}
+	return conn.ExecContext(ctx, t.pool.rewrite(ctx, query), args...)
+}
+
+// isSavePointStatement 判断是否为 SAVEPOINT / RELEASE SAVEPOINT / ROLLBACK TO 语句。
+func isSavePointStatement(query string) bool {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	return strings.HasPrefix(query, "SAVEPOINT") || strings.HasPrefix(query, "RELEASE") || strings.HasPrefix(query, "ROLLBACK TO")
+}
+
+// QueryContext 实现 gorm.ConnPool。
+func (t *connTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	t.statements.Add(1)
+	return t.ConnPool.QueryContext(ctx, t.pool.rewrite(ctx, query), args...)
+}
+
+// QueryRowContext 实现 gorm.ConnPool。
+func (t *connTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	t.statements.Add(1)
+	return t.ConnPool.QueryRowContext(ctx, t.pool.rewrite(ctx, query), args...)
+}
+
+// StmtContext 实现 gorm.Tx。
+func (t *connTx) StmtContext(ctx context.Context, stmt *sql.Stmt) *sql.Stmt {
+	if tx, ok := t.ConnPool.(interface {
+		StmtContext(ctx context.Context, stmt *sql.Stmt) *sql.Stmt
+	}); ok {
+		return tx.StmtContext(ctx, stmt)
+	}
+	return stmt
+}
+
+// GetDBConn 实现 gorm.GetDBConnector，保证事务内 db.DB() 可用。
+func (t *connTx) GetDBConn() (*sql.DB, error) {
+	return t.pool.GetDBConn()
+}
+
+// Commit 实现 gorm.TxCommitter。
+func (t *connTx) Commit() error {
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	err := committer.Commit()
+	t.finish(err == nil)
+	return err
+}
+
+// Rollback 实现 gorm.TxCommitter。
+func (t *connTx) Rollback() error {
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	err := committer.Rollback()
+	t.finish(false)
+	return err
+}
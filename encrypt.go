@@ -0,0 +1,544 @@
+package gormx
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrNoKeyProvider 表示读写 Encrypted 字段前没有通过 SetKeyProvider 设置密钥。
+	ErrNoKeyProvider = errors.New("gormx: encryption key provider is not set")
+	// ErrUnknownKey 表示密文的密钥 ID 在 KeyProvider 中不存在，可通过 errors.Is 判断。
+	ErrUnknownKey = errors.New("gormx: unknown encryption key")
+)
+
+// KeyProvider 为字段加密提供密钥。每条密文都带有加密时使用的密钥 ID，
+// 轮换时新增密钥并切换 CurrentKey，旧密钥需保留到 ReEncrypt 完成。
+type KeyProvider interface {
+	// CurrentKey 返回用于加密的当前密钥及其 ID（AES-128/192/256，16/24/32 字节）。
+	CurrentKey() (id string, key []byte, err error)
+	// Key 返回指定 ID 的密钥，用于解密；不存在时返回 ErrUnknownKey。
+	Key(id string) ([]byte, error)
+	// IndexKey 返回计算盲索引的 HMAC 密钥，更换后需要重新计算全部盲索引列。
+	IndexKey() ([]byte, error)
+}
+
+// keyProvider 为全局的 KeyProvider（sql.Scanner 没有 context，无法按连接传递）。
+var keyProvider atomic.Pointer[KeyProvider]
+
+// SetKeyProvider 设置 Encrypted 字段与盲索引使用的 KeyProvider，应在读写数据库之前调用。
+func SetKeyProvider(p KeyProvider) {
+	keyProvider.Store(&p)
+}
+
+// currentKeyProvider 返回已设置的 KeyProvider。
+func currentKeyProvider() (KeyProvider, error) {
+	if p := keyProvider.Load(); p != nil && *p != nil {
+		return *p, nil
+	}
+	return nil, ErrNoKeyProvider
+}
+
+// staticKeyProvider 为内存中的固定密钥集合。
+type staticKeyProvider struct {
+	current  string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// NewStaticKeyProvider 由固定的密钥集合构造 KeyProvider，current 为加密使用的密钥 ID，
+// 密钥通常来自 KMS 或配置中心；密钥 ID 不能为空或包含冒号。
+func NewStaticKeyProvider(current string, keys map[string][]byte, indexKey []byte) (KeyProvider, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, current)
+	}
+	for id, key := range keys {
+		if err := validateKeyId(id); err != nil {
+			return nil, err
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("gormx: encryption key %q: %w", id, err)
+		}
+	}
+	if len(indexKey) < 16 {
+		return nil, errors.New("gormx: blind index key must be at least 16 bytes")
+	}
+	return &staticKeyProvider{current: current, keys: keys, indexKey: indexKey}, nil
+}
+
+// CurrentKey 实现 KeyProvider。
+func (p *staticKeyProvider) CurrentKey() (string, []byte, error) {
+	return p.current, p.keys[p.current], nil
+}
+
+// Key 实现 KeyProvider。
+func (p *staticKeyProvider) Key(id string) ([]byte, error) {
+	if key, ok := p.keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+}
+
+// IndexKey 实现 KeyProvider。
+func (p *staticKeyProvider) IndexKey() ([]byte, error) {
+	return p.indexKey, nil
+}
+
+// validateKeyId 校验密钥 ID，密文以第一个冒号分隔密钥 ID 与内容。
+func validateKeyId(id string) error {
+	if id == "" || strings.Contains(id, ":") {
+		return fmt.Errorf("gormx: invalid encryption key id %q", id)
+	}
+	return nil
+}
+
+// Encrypted 为加密存储的字段：写入时以 AES-GCM 加密为 "<密钥 ID>:<base64 密文>"，扫描时解密。
+// string 与 []byte 直接加密原始内容，其余类型先序列化为 JSON；NULL 与空字符串扫描为零值。
+// 序列化为 JSON（如接口响应）时输出明文 Data，需要脱敏时由调用方处理。
+//
+// 密文与 "<表名>.<列名>" 绑定（作为 GCM 附加数据），复制到其它表或列后无法解密；
+// 但不与行绑定，有写权限的攻击者仍可在同一列的不同行之间交换密文，需要时由业务在明文中包含行标识。
+// Encrypted 以 gorm 序列化器的方式读写（表名与列名取自模型），不能直接作为原生 SQL 或查询条件的参数，
+// 等值查询使用 BlindIndex。
+type Encrypted[T any] struct {
+	Data T
+	// keyId 为扫描时密文使用的密钥 ID。
+	keyId string
+}
+
+// NewEncrypted 包装 v
+func NewEncrypted[T any](v T) Encrypted[T] {
+	return Encrypted[T]{Data: v}
+}
+
+// KeyID 返回扫描时密文使用的密钥 ID，新建的值为空
+func (e Encrypted[T]) KeyID() string {
+	return e.keyId
+}
+
+// Scan 实现 schema.SerializerInterface，按字段所属的表与列解密
+func (e *Encrypted[T]) Scan(_ context.Context, field *schema.Field, _ reflect.Value, dbValue interface{}) error {
+	switch dbValue.(type) {
+	case nil, string, []byte:
+	default:
+		return fmt.Errorf("gormx: cannot scan %T into Encrypted", dbValue)
+	}
+	ciphertext, ok := ciphertextOf(dbValue)
+	if !ok {
+		*e = Encrypted[T]{}
+		return nil
+	}
+
+	keyId, plaintext, err := decrypt(field.Schema.Table, field.DBName, ciphertext)
+	if err != nil {
+		return err
+	}
+	var data T
+	if err = decodePlaintext(plaintext, &data); err != nil {
+		return err
+	}
+	e.Data, e.keyId = data, keyId
+	return nil
+}
+
+// Value 实现 schema.SerializerValuerInterface，使用当前密钥加密（每次写入使用新的随机 nonce）
+func (e Encrypted[T]) Value(_ context.Context, field *schema.Field, _ reflect.Value, _ interface{}) (interface{}, error) {
+	plaintext, err := e.plaintext()
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(field.Schema.Table, field.DBName, plaintext)
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface
+func (Encrypted[T]) GormDataType() string {
+	return "string"
+}
+
+// GormDBDataType 返回列类型，密文长度随明文变化，统一使用 text
+func (Encrypted[T]) GormDBDataType(*gorm.DB, *schema.Field) string {
+	return "text"
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (e Encrypted[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Data)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (e *Encrypted[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &e.Data)
+}
+
+// plaintext 实现 encryptedValue
+func (e Encrypted[T]) plaintext() ([]byte, error) {
+	return encodePlaintext(e.Data)
+}
+
+// encryptedValue 为 Encrypted 的类型无关接口，用于识别加密字段与计算盲索引。
+type encryptedValue interface {
+	plaintext() ([]byte, error)
+}
+
+// encodePlaintext 将值编码为待加密的明文。
+func encodePlaintext(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case encryptedValue:
+		return v.plaintext()
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// decodePlaintext 将解密后的明文解码到 dst。
+func decodePlaintext(plaintext []byte, dst interface{}) error {
+	switch dst := dst.(type) {
+	case *string:
+		*dst = string(plaintext)
+		return nil
+	case *[]byte:
+		*dst = plaintext
+		return nil
+	default:
+		return json.Unmarshal(plaintext, dst)
+	}
+}
+
+// additionalData 返回 GCM 附加数据：密钥 ID 与 "<表名>.<列名>"，
+// 防止密文被改写为其它密钥 ID，或被复制到其它表、列后解密。
+func additionalData(keyId, table, column string) []byte {
+	return []byte(keyId + ":" + table + "." + column)
+}
+
+// encrypt 使用当前密钥加密 table.column 的值，返回 "<密钥 ID>:<base64(nonce + 密文)>"。
+func encrypt(table, column string, plaintext []byte) (string, error) {
+	p, err := currentKeyProvider()
+	if err != nil {
+		return "", err
+	}
+	keyId, key, err := p.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	// 自定义 KeyProvider 返回的密钥 ID 同样需要校验，否则写入的密文无法解密。
+	if err = validateKeyId(keyId); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData(keyId, table, column))
+	return keyId + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt 按密文中的密钥 ID 解密 table.column 的值。
+func decrypt(table, column, ciphertext string) (keyId string, plaintext []byte, err error) {
+	keyId, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok || keyId == "" {
+		return "", nil, errors.New("gormx: malformed ciphertext")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("gormx: malformed ciphertext: %w", err)
+	}
+
+	p, err := currentKeyProvider()
+	if err != nil {
+		return "", nil, err
+	}
+	key, err := p.Key(keyId)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", nil, errors.New("gormx: malformed ciphertext")
+	}
+	plaintext, err = aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData(keyId, table, column))
+	if err != nil {
+		return "", nil, fmt.Errorf("gormx: decrypt %s.%s with key %q: %w", table, column, keyId, err)
+	}
+	return keyId, plaintext, nil
+}
+
+// newAEAD 构造 AES-GCM。
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// BlindIndex 返回 table.column 中值为 value 的盲索引（HMAC-SHA256 的十六进制），用于按加密字段等值查询：
+// db.Where("email_index = ?", idx)。column 为加密的来源列，每列使用由 IndexKey 派生的独立密钥，
+// 相同明文在不同列的盲索引不同。value 可以是明文或 Encrypted，大小写等规范化需由调用方在写入与查询时保持一致。
+func BlindIndex(table, column string, value interface{}) (string, error) {
+	plaintext, err := encodePlaintext(value)
+	if err != nil {
+		return "", err
+	}
+	p, err := currentKeyProvider()
+	if err != nil {
+		return "", err
+	}
+	indexKey, err := p.IndexKey()
+	if err != nil {
+		return "", err
+	}
+	derive := hmac.New(sha256.New, indexKey)
+	derive.Write([]byte(table + "." + column))
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write(plaintext)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+const (
+	// defaultReEncryptBatchSize 为默认的每批扫描行数。
+	defaultReEncryptBatchSize = 200
+	// defaultReEncryptBatchInterval 为默认的批次间隔。
+	defaultReEncryptBatchInterval = 200 * time.Millisecond
+)
+
+// ReEncryptConf 为密钥轮换后重新加密的配置。
+type ReEncryptConf struct {
+	// 每批扫描行数（默认200），每行单独更新
+	BatchSize int `json:"batch_size"`
+	// 批次间隔（毫秒，默认200），用于限流
+	BatchInterval int `json:"batch_interval"`
+}
+
+// ReEncryptStats 为一次重新加密的统计。
+type ReEncryptStats struct {
+	// Table 为表名。
+	Table string `json:"table"`
+	// Scanned 为扫描的行数，ReEncrypted 为重新加密的行数，Batches 为批次数。
+	Scanned     int64 `json:"scanned"`
+	ReEncrypted int64 `json:"re_encrypted"`
+	Batches     int64 `json:"batches"`
+	// Conflicts 为重新加密期间被并发修改而跳过的行数（修改时已使用当前密钥，无需处理）。
+	Conflicts int64 `json:"conflicts"`
+}
+
+// ReEncrypt 按主键分批扫描 model 对应的表（包括已软删除的记录），将非当前密钥加密的 Encrypted 列解密后以当前密钥重新加密。
+// 直接处理密文，不经过模型钩子与插件；每行以原密文作为条件更新，期间被并发修改的行会跳过。模型需要单列主键。
+func ReEncrypt(ctx context.Context, db *gorm.DB, model interface{}, conf ReEncryptConf) (ReEncryptStats, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return ReEncryptStats{}, err
+	}
+	s := stmt.Schema
+	stats := ReEncryptStats{Table: s.Table}
+	if len(s.PrimaryFields) != 1 {
+		return stats, fmt.Errorf("gormx: re-encrypt %s: requires a single primary key", s.Table)
+	}
+	pk := s.PrimaryFields[0].DBName
+
+	var columns []string
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if _, ok := reflect.New(field.IndirectFieldType).Elem().Interface().(encryptedValue); ok {
+			columns = append(columns, field.DBName)
+		}
+	}
+	if len(columns) == 0 {
+		return stats, nil
+	}
+
+	p, err := currentKeyProvider()
+	if err != nil {
+		return stats, err
+	}
+	current, _, err := p.CurrentKey()
+	if err != nil {
+		return stats, err
+	}
+
+	batchSize, batchInterval := conf.BatchSize, time.Millisecond*time.Duration(conf.BatchInterval)
+	if batchSize <= 0 {
+		batchSize = defaultReEncryptBatchSize
+	}
+	if batchInterval <= 0 {
+		batchInterval = defaultReEncryptBatchInterval
+	}
+
+	db = db.WithContext(ctx)
+	pkColumn := clause.Column{Name: pk}
+	selects := clause.Select{Columns: []clause.Column{pkColumn}}
+	for _, column := range columns {
+		selects.Columns = append(selects.Columns, clause.Column{Name: column})
+	}
+	var last interface{}
+	for {
+		query := db.Table(s.Table).Clauses(selects).Order(clause.OrderByColumn{Column: pkColumn}).Limit(batchSize)
+		if last != nil {
+			query = query.Where(clause.Gt{Column: pkColumn, Value: last})
+		}
+		var rows []map[string]interface{}
+		if err = query.Find(&rows).Error; err != nil || len(rows) == 0 {
+			break
+		}
+		stats.Batches++
+		stats.Scanned += int64(len(rows))
+		last = rows[len(rows)-1][pk]
+
+		for _, row := range rows {
+			var (
+				values = make(map[string]interface{})
+				where  = []clause.Expression{clause.Eq{Column: pkColumn, Value: row[pk]}}
+			)
+			for _, column := range columns {
+				ciphertext, ok := ciphertextOf(row[column])
+				if !ok || strings.HasPrefix(ciphertext, current+":") {
+					continue
+				}
+				_, plaintext, err := decrypt(s.Table, column, ciphertext)
+				if err != nil {
+					return stats, fmt.Errorf("gormx: re-encrypt %s %s=%v: %w", s.Table, pk, row[pk], err)
+				}
+				if values[column], err = encrypt(s.Table, column, plaintext); err != nil {
+					return stats, err
+				}
+				where = append(where, clause.Eq{Column: clause.Column{Name: column}, Value: row[column]})
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			result := db.Table(s.Table).Where(clause.And(where...)).UpdateColumns(values)
+			if result.Error != nil {
+				return stats, result.Error
+			}
+			if result.RowsAffected == 0 {
+				stats.Conflicts++
+			} else {
+				stats.ReEncrypted++
+			}
+		}
+		if len(rows) < batchSize {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(batchInterval):
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	db.Logger.Info(ctx, "re-encrypt %s: scanned %d, re-encrypted %d, conflicts %d", s.Table, stats.Scanned, stats.ReEncrypted, stats.Conflicts)
+	return stats, err
+}
+
+// ciphertextOf 将扫描到的列值转换为密文字符串，NULL 与空值返回 false。
+func ciphertextOf(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case []byte:
+		return string(v), len(v) > 0
+	default:
+		return "", false
+	}
+}
+
+// encryptPlugin 在创建与更新前加密 map 中的 Encrypted 值（结构体字段由序列化器加密），
+// 需在盲索引插件之后执行，以便盲索引使用明文。
+type encryptPlugin struct{}
+
+// Name 实现 gorm.Plugin。
+func (encryptPlugin) Name() string {
+	return "gormx:encrypt"
+}
+
+// Initialize 实现 gorm.Plugin。
+func (p encryptPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(p.Name(), p.encryptMaps); err != nil {
+		return err
+	}
+	return cb.Update().Before("gorm:update").Register(p.Name(), p.encryptMaps)
+}
+
+// encryptMaps 将 map 中的 Encrypted 值替换为按模型表名与列名加密的密文。
+func (encryptPlugin) encryptMaps(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil {
+		return
+	}
+
+	encryptMap := func(m map[string]interface{}) {
+		for key, value := range m {
+			v, ok := value.(encryptedValue)
+			if !ok {
+				continue
+			}
+			if stmt.Schema == nil {
+				_ = db.AddError(fmt.Errorf("gormx: encrypted column %q requires a model", key))
+				return
+			}
+			column := key
+			if field := stmt.Schema.LookUpField(key); field != nil && field.DBName != "" {
+				column = field.DBName
+			}
+			plaintext, err := v.plaintext()
+			if err == nil {
+				m[key], err = encrypt(stmt.Schema.Table, column, plaintext)
+			}
+			if err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		encryptMap(dest)
+	case *map[string]interface{}:
+		encryptMap(*dest)
+	case []map[string]interface{}:
+		for _, m := range dest {
+			encryptMap(m)
+		}
+	case *[]map[string]interface{}:
+		for _, m := range *dest {
+			encryptMap(m)
+		}
+	}
+}
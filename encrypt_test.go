@@ -0,0 +1,232 @@
+package gormx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fireflycore/gormx/internal/testdb"
+)
+
+// secretProfile 为以 JSON 加密存储的结构
+type secretProfile struct {
+	Phone string `json:"phone"`
+}
+
+// secretUser 为带加密字段与盲索引的测试模型
+type secretUser struct {
+	Id         uint64
+	Email      Encrypted[string]
+	EmailIndex string `gormx:"blind_index:Email"`
+	Profile    Encrypted[secretProfile]
+}
+
+// rotatingKeys 为测试使用的 k1/k2 两个密钥
+var rotatingKeys = map[string][]byte{
+	"k1": []byte("0123456789abcdef0123456789abcdef"),
+	"k2": []byte("fedcba9876543210fedcba9876543210"),
+}
+
+// useKeys 设置当前密钥为 current 的 KeyProvider
+func useKeys(t *testing.T, current string) {
+	t.Helper()
+	p, err := NewStaticKeyProvider(current, rotatingKeys, []byte("index-key-0123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetKeyProvider(p)
+}
+
+// badKeyProvider 返回非法的当前密钥 ID
+type badKeyProvider struct{}
+
+func (badKeyProvider) CurrentKey() (string, []byte, error) { return "a:b", rotatingKeys["k1"], nil }
+func (badKeyProvider) Key(string) ([]byte, error)          { return rotatingKeys["k1"], nil }
+func (badKeyProvider) IndexKey() ([]byte, error)           { return []byte("index-key-0123456789"), nil }
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	useKeys(t, "k1")
+	db, fake := newTestDB(t, "postgres", nil)
+
+	var inserted []driver.Value
+	fake.Query = func(query string, args []driver.Value) (*testdb.Rows, error) {
+		if strings.HasPrefix(query, "INSERT") {
+			inserted = args
+			return &testdb.Rows{Columns: []string{"id"}, Values: [][]driver.Value{{int64(1)}}}, nil
+		}
+		return &testdb.Rows{
+			Columns: []string{"id", "email", "email_index", "profile"},
+			Values:  [][]driver.Value{{int64(1), inserted[0], inserted[1], inserted[2]}},
+		}, nil
+	}
+
+	user := secretUser{Email: NewEncrypted("a@example.com"), Profile: NewEncrypted(secretProfile{Phone: "123"})}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(inserted) != 3 {
+		t.Fatalf("inserted %v", inserted)
+	}
+	for _, i := range []int{0, 2} {
+		if ciphertext := inserted[i].(string); !strings.HasPrefix(ciphertext, "k1:") || strings.Contains(ciphertext, "example") {
+			t.Fatalf("column %d not encrypted: %q", i, ciphertext)
+		}
+	}
+	idx, err := BlindIndex("secret_users", "email", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted[1] != idx || user.EmailIndex != idx {
+		t.Fatalf("blind index = %v, want %s", inserted[1], idx)
+	}
+
+	var got secretUser
+	if err = db.First(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Email.Data != "a@example.com" || got.Profile.Data.Phone != "123" || got.Email.KeyID() != "k1" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestEncryptedMapValues(t *testing.T) {
+	useKeys(t, "k1")
+	db, fake := newTestDB(t, "postgres", nil)
+
+	var args []driver.Value
+	fake.Exec = func(query string, a []driver.Value) (int64, error) {
+		args = a
+		return 1, nil
+	}
+	if err := db.Model(&secretUser{Id: 1}).Updates(map[string]interface{}{"email": NewEncrypted("b@example.com")}).Error; err != nil {
+		t.Fatal(err)
+	}
+	update := findStatement(t, fake.Statements(), "UPDATE")
+	if !strings.Contains(update, `"email_index"`) {
+		t.Fatalf("blind index not updated: %s", update)
+	}
+
+	idx, _ := BlindIndex("secret_users", "email", "b@example.com")
+	var ciphertext string
+	for _, arg := range args {
+		if s, ok := arg.(string); ok && strings.HasPrefix(s, "k1:") {
+			ciphertext = s
+		}
+	}
+	if _, plaintext, err := decrypt("secret_users", "email", ciphertext); err != nil || string(plaintext) != "b@example.com" {
+		t.Fatalf("decrypt %q: %s %v", ciphertext, plaintext, err)
+	}
+	if !strings.Contains(update, idx) {
+		t.Fatalf("blind index %s missing: %s", idx, update)
+	}
+}
+
+func TestEncryptedBoundToColumn(t *testing.T) {
+	useKeys(t, "k1")
+	ciphertext, err := encrypt("users", "email", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = decrypt("users", "email", ciphertext); err != nil {
+		t.Fatal(err)
+	}
+	// 复制到其它列或其它表的密文无法解密
+	if _, _, err = decrypt("users", "phone", ciphertext); err == nil {
+		t.Fatal("decrypted ciphertext copied to another column")
+	}
+	if _, _, err = decrypt("accounts", "email", ciphertext); err == nil {
+		t.Fatal("decrypted ciphertext copied to another table")
+	}
+	// 改写密钥 ID 后无法解密
+	if _, _, err = decrypt("users", "email", "k2"+strings.TrimPrefix(ciphertext, "k1")); err == nil {
+		t.Fatal("decrypted ciphertext with rewritten key id")
+	}
+	if _, _, err = decrypt("users", "email", "k3"+strings.TrimPrefix(ciphertext, "k1")); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("err = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestKeyIdValidation(t *testing.T) {
+	if _, err := NewStaticKeyProvider("a:b", map[string][]byte{"a:b": rotatingKeys["k1"]}, []byte("index-key-0123456789")); err == nil {
+		t.Fatal("accepted key id with colon")
+	}
+
+	SetKeyProvider(badKeyProvider{})
+	if _, err := encrypt("users", "email", []byte("secret")); err == nil {
+		t.Fatal("encrypted with key id containing colon")
+	}
+}
+
+func TestBlindIndexPerColumn(t *testing.T) {
+	useKeys(t, "k1")
+	email, _ := BlindIndex("users", "email", "same")
+	again, _ := BlindIndex("users", "email", NewEncrypted("same"))
+	phone, _ := BlindIndex("users", "phone", "same")
+	other, _ := BlindIndex("accounts", "email", "same")
+	if email != again {
+		t.Fatalf("blind index not deterministic: %s != %s", email, again)
+	}
+	if email == phone || email == other {
+		t.Fatal("blind index shared across columns")
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	useKeys(t, "k1")
+	old, _ := encrypt("secret_users", "email", []byte("a@example.com"))
+	profile, _ := encrypt("secret_users", "profile", []byte(`{"phone":"1"}`))
+	useKeys(t, "k2")
+	current, _ := encrypt("secret_users", "email", []byte("b@example.com"))
+
+	db, fake := newTestDB(t, "postgres", nil)
+	fake.Query = func(query string, args []driver.Value) (*testdb.Rows, error) {
+		if len(args) > 1 {
+			// 第二批（id > 上一批最后的主键）为空
+			return nil, nil
+		}
+		return &testdb.Rows{
+			Columns: []string{"id", "email", "profile"},
+			Values: [][]driver.Value{
+				{int64(1), old, profile},
+				{int64(2), current, nil},
+			},
+		}, nil
+	}
+	var updates [][]driver.Value
+	fake.Exec = func(query string, args []driver.Value) (int64, error) {
+		updates = append(updates, args)
+		return 1, nil
+	}
+
+	stats, err := ReEncrypt(context.Background(), db, &secretUser{}, ReEncryptConf{BatchSize: 2, BatchInterval: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Scanned != 2 || stats.ReEncrypted != 1 || stats.Batches != 1 || stats.Conflicts != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if len(updates) != 1 {
+		t.Fatalf("updates = %v", updates)
+	}
+	update := findStatement(t, fake.Statements(), "UPDATE")
+	if !strings.Contains(update, old) || !strings.Contains(update, profile) {
+		t.Fatalf("update not conditioned on old ciphertexts: %s", update)
+	}
+	for _, arg := range updates[0][:2] {
+		ciphertext := arg.(string)
+		if !strings.HasPrefix(ciphertext, "k2:") {
+			t.Fatalf("not re-encrypted with current key: %s", ciphertext)
+		}
+	}
+	if _, plaintext, err := decrypt("secret_users", "email", updates[0][0].(string)); err != nil || string(plaintext) != "a@example.com" {
+		t.Fatalf("decrypt: %s %v", plaintext, err)
+	}
+
+	// 期间被并发修改的行跳过
+	fake.Exec = func(string, []driver.Value) (int64, error) { return 0, nil }
+	if stats, err = ReEncrypt(context.Background(), db, &secretUser{}, ReEncryptConf{BatchSize: 2, BatchInterval: 1}); err != nil || stats.Conflicts != 1 {
+		t.Fatalf("stats = %+v, err = %v", stats, err)
+	}
+}
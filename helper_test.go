@@ -0,0 +1,53 @@
+package gormx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fireflycore/gormx/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// newTestDB 以 dialect 方言打开挂载 gormx 插件的测试连接，conf 可调整默认配置
+func newTestDB(t *testing.T, dialect string, conf func(c *Conf)) (*gorm.DB, *testdb.DB) {
+	t.Helper()
+	c := &Conf{Database: "test", Tracing: TracingConf{Disable: true}}
+	c.WithMetadataExtractor(NewContextMetadataExtractor())
+	if conf != nil {
+		conf(c)
+	}
+
+	fake := &testdb.DB{}
+	db, err := fake.Open(dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = usePlugins(db, c); err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+// tenantContext 返回携带租户与用户的请求上下文
+func tenantContext(tenantId, userId string) context.Context {
+	return WithMetadata(context.Background(), Metadata{TenantId: tenantId, UserId: userId})
+}
+
+// dryRun 返回 fn 在 DryRun 会话中生成的 SQL
+func dryRun(db *gorm.DB, fn func(tx *gorm.DB) *gorm.DB) (string, error) {
+	tx := fn(db.Session(&gorm.Session{DryRun: true}))
+	return tx.Statement.SQL.String(), tx.Error
+}
+
+// findStatement 返回第一条包含 substr 的语句
+func findStatement(t *testing.T, statements []string, substr string) string {
+	t.Helper()
+	for _, s := range statements {
+		if strings.Contains(s, substr) {
+			return s
+		}
+	}
+	t.Fatalf("no statement contains %q in:\n%s", substr, strings.Join(statements, "\n"))
+	return ""
+}
@@ -0,0 +1,146 @@
+package gormx
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/fireflycore/gormx/internal"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// IDGeneratorUUIDv7 为内置 UUIDv7 生成器的名称（字符串形式）。
+	IDGeneratorUUIDv7 = "uuidv7"
+	// IDGeneratorULID 为内置 ULID 生成器的名称。
+	IDGeneratorULID = "ulid"
+	// IDGeneratorSnowflake 为 Snowflake 生成器的约定名称，需通过 Conf.WithIDGenerator 注册（节点号因实例而异）。
+	IDGeneratorSnowflake = "snowflake"
+)
+
+// IDGenerator 为主键生成器，通过 gormx:"id:<name>" 标签在创建时为字段赋值。
+type IDGenerator interface {
+	// Generate 生成一个新 ID，返回值需可赋给目标字段（string、int64、UUID 等）。
+	Generate() (interface{}, error)
+}
+
+// IDGeneratorFunc 为函数形式的 IDGenerator。
+type IDGeneratorFunc func() (interface{}, error)
+
+// Generate 实现 IDGenerator。
+func (f IDGeneratorFunc) Generate() (interface{}, error) {
+	return f()
+}
+
+// UUIDv7Generator 生成字符串形式的 UUIDv7，随机源失败时返回错误。
+type UUIDv7Generator struct{}
+
+// Generate 实现 IDGenerator。
+func (UUIDv7Generator) Generate() (interface{}, error) {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	return u.String(), nil
+}
+
+// WithIDGenerator 注册名为 name 的 ID 生成器（同名覆盖内置的 uuidv7 / ulid）。
+func (c *Conf) WithIDGenerator(name string, generator IDGenerator) {
+	if c.idGenerators == nil {
+		c.idGenerators = make(map[string]IDGenerator)
+	}
+	c.idGenerators[name] = generator
+}
+
+// idPlugin 在创建前为 gormx:"id:<name>" 标签的零值字段生成 ID。
+type idPlugin struct {
+	generators map[string]IDGenerator
+	// fields 缓存每个模型的 ID 字段，key 为 *schema.Schema。
+	fields sync.Map
+}
+
+// newIDPlugin 构造 ID 插件，内置 uuidv7 与 ulid，再合并 Conf 中注册的生成器。
+func newIDPlugin(c *Conf) *idPlugin {
+	p := &idPlugin{generators: map[string]IDGenerator{
+		IDGeneratorUUIDv7: UUIDv7Generator{},
+		IDGeneratorULID:   NewULIDGenerator(),
+	}}
+	for name, generator := range c.idGenerators {
+		p.generators[name] = generator
+	}
+	return p
+}
+
+// Name 实现 gorm.Plugin。
+func (p *idPlugin) Name() string {
+	return "gormx:id"
+}
+
+// Initialize 实现 gorm.Plugin，在 BeforeCreate 钩子之前赋值，钩子中即可读取 ID。
+func (p *idPlugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:before_create").Register(p.Name(), p.assign)
+}
+
+// assign 为待创建记录的 ID 字段赋值（已有值的不覆盖）。
+func (p *idPlugin) assign(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+
+	fields := p.idFields(stmt.Schema)
+	if len(fields) == 0 {
+		return
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if err := p.assignRow(stmt, fields, stmt.ReflectValue.Index(i)); err != nil {
+				_ = db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := p.assignRow(stmt, fields, stmt.ReflectValue); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
+
+// assignRow 为单条记录赋值。
+func (p *idPlugin) assignRow(stmt *gorm.Statement, fields []internal.IDField, row reflect.Value) error {
+	row = reflect.Indirect(row)
+	if row.Kind() != reflect.Struct {
+		return nil
+	}
+	for _, f := range fields {
+		if _, zero := f.Field.ValueOf(stmt.Context, row); !zero {
+			continue
+		}
+		generator, ok := p.generators[f.Generator]
+		if !ok {
+			return fmt.Errorf("gormx: unknown id generator %q on %s.%s", f.Generator, stmt.Schema.Name, f.Field.Name)
+		}
+		id, err := generator.Generate()
+		if err != nil {
+			return fmt.Errorf("gormx: generate id for %s.%s: %w", stmt.Schema.Name, f.Field.Name, err)
+		}
+		if err = f.Field.Set(stmt.Context, row, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// idFields 返回并缓存模型的 ID 字段。
+func (p *idPlugin) idFields(s *schema.Schema) []internal.IDField {
+	if v, ok := p.fields.Load(s); ok {
+		return v.([]internal.IDField)
+	}
+	fields := internal.IDFields(s)
+	p.fields.Store(s, fields)
+	return fields
+}
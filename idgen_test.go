@@ -0,0 +1,172 @@
+package gormx
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock 依次返回 times 中的时间，用尽后停在最后一个
+func fakeClock(times ...time.Time) func() time.Time {
+	return func() time.Time {
+		now := times[0]
+		if len(times) > 1 {
+			times = times[1:]
+		}
+		return now
+	}
+}
+
+func TestSnowflakeNodeBounds(t *testing.T) {
+	for _, node := range []int64{-1, snowflakeMaxNode + 1} {
+		if _, err := NewSnowflake(SnowflakeConf{Node: node}); err == nil {
+			t.Fatalf("node %d accepted", node)
+		}
+	}
+	for _, node := range []int64{0, snowflakeMaxNode} {
+		s, err := NewSnowflake(SnowflakeConf{Node: node})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := s.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := id >> snowflakeSeqBits & snowflakeMaxNode; got != node {
+			t.Fatalf("node bits = %d, want %d", got, node)
+		}
+	}
+}
+
+func TestSnowflakeClockBackward(t *testing.T) {
+	epoch := defaultSnowflakeEpoch
+	s, err := NewSnowflake(SnowflakeConf{Node: 1, MaxClockBackward: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 回拨 3ms 时等待时钟追上，ID 仍然递增
+	s.now = fakeClock(epoch.Add(5*time.Millisecond), epoch.Add(2*time.Millisecond), epoch.Add(6*time.Millisecond))
+	first, err := s.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second <= first || second>>(snowflakeNodeBits+snowflakeSeqBits) != 6 {
+		t.Fatalf("ids after rollback: %d, %d", first, second)
+	}
+
+	// 回拨超过 MaxClockBackward 时拒绝生成
+	s.now = fakeClock(epoch)
+	if _, err = s.NextID(); !errors.Is(err, ErrClockBackward) {
+		t.Fatalf("err = %v, want ErrClockBackward", err)
+	}
+}
+
+func TestSnowflakeSequenceExhausted(t *testing.T) {
+	epoch := defaultSnowflakeEpoch
+	s, _ := NewSnowflake(SnowflakeConf{})
+	s.now = fakeClock(epoch.Add(time.Millisecond))
+	var last int64
+	for i := 0; i <= snowflakeMaxSeq; i++ {
+		id, err := s.NextID()
+		if err != nil || id <= last {
+			t.Fatalf("id %d after %d: %v", id, last, err)
+		}
+		last = id
+	}
+	// 序列号用尽后等待下一毫秒
+	s.now = fakeClock(epoch.Add(time.Millisecond), epoch.Add(2*time.Millisecond))
+	id, err := s.NextID()
+	if err != nil || id <= last || id>>(snowflakeNodeBits+snowflakeSeqBits) != 2 {
+		t.Fatalf("id %d after %d: %v", id, last, err)
+	}
+}
+
+func TestULIDMonotonic(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	g := NewULIDGenerator()
+	// 同一毫秒与时钟回拨时保持严格递增
+	g.now = fakeClock(now, now, now, now.Add(-time.Second), now.Add(time.Millisecond))
+	var last string
+	for i := 0; i < 5; i++ {
+		id, err := g.NewULID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 26 || id <= last {
+			t.Fatalf("ulid %q after %q", id, last)
+		}
+		last = id
+	}
+
+	g.now = fakeClock(now.Add(time.Millisecond))
+	for i := range g.entropy {
+		g.entropy[i] = 0xff
+	}
+	if _, err := g.NewULID(); !errors.Is(err, ErrULIDOverflow) {
+		t.Fatalf("err = %v, want ErrULIDOverflow", err)
+	}
+}
+
+// idModel 为带 ID 标签的测试模型
+type idModel struct {
+	Id   int64  `gorm:"primaryKey;autoIncrement:false" gormx:"id:snowflake"`
+	Code string `gormx:"id:ulid"`
+	Ref  string `gormx:"id:uuidv7"`
+}
+
+// unknownIdModel 使用未注册的生成器
+type unknownIdModel struct {
+	Id string `gorm:"primaryKey" gormx:"id:missing"`
+}
+
+func TestIDPluginAssign(t *testing.T) {
+	snowflake, _ := NewSnowflake(SnowflakeConf{Node: 7})
+	db, _ := newTestDB(t, "postgres", func(c *Conf) {
+		c.WithIDGenerator(IDGeneratorSnowflake, snowflake)
+	})
+
+	one := idModel{Code: "keep"}
+	if err := db.Create(&one).Error; err != nil {
+		t.Fatal(err)
+	}
+	if one.Id == 0 || one.Code != "keep" || len(one.Ref) != 36 {
+		t.Fatalf("struct create: %+v", one)
+	}
+
+	many := []idModel{{}, {}, {Id: 42}}
+	if err := db.Create(&many).Error; err != nil {
+		t.Fatal(err)
+	}
+	if many[0].Id == 0 || many[0].Id == many[1].Id || many[2].Id != 42 {
+		t.Fatalf("slice create: %+v", many)
+	}
+	if many[0].Code == "" || many[0].Code >= many[1].Code {
+		t.Fatalf("ulids not assigned in order: %q, %q", many[0].Code, many[1].Code)
+	}
+
+	err := db.Create(&unknownIdModel{}).Error
+	if err == nil || !strings.Contains(err.Error(), `unknown id generator "missing"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestTableBeforeCreate(t *testing.T) {
+	var table TableUUID
+	if err := table.BeforeCreate(nil); err != nil || len(table.Id) != 36 {
+		t.Fatalf("TableUUID: %q, %v", table.Id, err)
+	}
+	id := table.Id
+	if err := table.BeforeCreate(nil); err != nil || table.Id != id {
+		t.Fatalf("TableUUID overwrote id: %q", table.Id)
+	}
+
+	var binary TableBinaryUUID
+	if err := binary.BeforeCreate(nil); err != nil || binary.Id.IsZero() {
+		t.Fatalf("TableBinaryUUID: %v, %v", binary.Id, err)
+	}
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// callerDepth 为定位调用方时最多回溯的栈帧数
+	callerDepth = 16
+	// callerCacheSize 为调用点缓存的上限，超过后不再写入缓存
+	callerCacheSize = 4096
+)
+
+// callerCache 以调用栈 PC 序列为 key 缓存解析出的 file:line，同一调用点只做一次符号解析
+var callerCache = struct {
+	sync.RWMutex
+	m map[[callerDepth]uintptr]string
+}{m: make(map[[callerDepth]uintptr]string)}
+
+// Caller 返回 gorm / gormx 之外的第一个调用方（file:line），结果按调用点缓存
+func Caller() string {
+	var pcs [callerDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+
+	callerCache.RLock()
+	file, ok := callerCache.m[pcs]
+	callerCache.RUnlock()
+	if ok {
+		return file
+	}
+
+	// 拷贝一份再解析，避免 pcs 逃逸到堆上
+	file = resolveCaller(append([]uintptr(nil), pcs[:n]...))
+
+	callerCache.Lock()
+	if len(callerCache.m) < callerCacheSize {
+		callerCache.m[pcs] = file
+	}
+	callerCache.Unlock()
+
+	return file
+}
+
+// resolveCaller 解析 PC 序列，跳过 gorm、gormx 与 otelgorm 内部的栈帧
+func resolveCaller(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.File != "" && !isInternalFrame(frame) {
+			return frame.File + ":" + strconv.FormatInt(int64(frame.Line), 10)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// isInternalFrame 判断栈帧是否属于 gorm / gormx 自身（测试文件除外）
+func isInternalFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, "gorm.io/") ||
+		strings.HasPrefix(frame.Function, "github.com/fireflycore/gormx") ||
+		strings.HasPrefix(frame.Function, "github.com/uptrace/opentelemetry-go-extra/otelgorm")
+}
+
+// stackDepth 为 CallerStack 最多记录的栈帧数
+const stackDepth = 32
+
+// CallerStack 记录当前调用栈的 PC 序列（开销远低于 debug.Stack），需要输出时再用 FormatStack 解析
+func CallerStack() []uintptr {
+	pcs := make([]uintptr, stackDepth)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+// FormatStack 将 CallerStack 的结果解析为多行 function (file:line)，跳过 gorm / gormx 内部与 runtime 栈帧
+func FormatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.File != "" && !isInternalFrame(frame) && !strings.HasPrefix(frame.Function, "runtime.") && !strings.HasPrefix(frame.Function, "database/sql.") {
+			b.WriteString(frame.Function)
+			b.WriteString(" (")
+			b.WriteString(frame.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.FormatInt(int64(frame.Line), 10))
+			b.WriteString(")\n")
+		}
+		if !more {
+			return strings.TrimSuffix(b.String(), "\n")
+		}
+	}
+}
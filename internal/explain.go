@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// explainTimeout 为单次 EXPLAIN 的超时时间
+	explainTimeout = 5 * time.Second
+	// explainHistorySize 为限流记录的上限，超过后清理过期记录
+	explainHistorySize = 4096
+)
+
+// explainer 为慢 SQL 执行 EXPLAIN，并按指纹限流
+type explainer struct {
+	// interval 为同一指纹两次 EXPLAIN 的最小间隔
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// newExplainer 构造 explainer，interval <= 0 时默认 1 分钟
+func newExplainer(interval time.Duration) *explainer {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &explainer{interval: interval, last: make(map[string]time.Time)}
+}
+
+// allow 判断该指纹当前是否允许 EXPLAIN（只针对 SELECT，写语句永不重放）
+func (e *explainer) allow(fingerprint, operation, sql string) bool {
+	if operation != OperationSelect || !strings.EqualFold(firstKeyword(sql), "SELECT") {
+		return false
+	}
+
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if last, ok := e.last[fingerprint]; ok && now.Sub(last) < e.interval {
+		return false
+	}
+	// 记录过多时清理已过期的指纹，避免无限增长
+	if len(e.last) >= explainHistorySize {
+		for k, t := range e.last {
+			if now.Sub(t) >= e.interval {
+				delete(e.last, k)
+			}
+		}
+		if len(e.last) >= explainHistorySize {
+			return false
+		}
+	}
+	e.last[fingerprint] = now
+	return true
+}
+
+// explain 在独立连接上执行 EXPLAIN 并以字符串返回执行计划
+func (e *explainer) explain(ctx context.Context, db *sql.DB, dialect, query string) string {
+	// 不随请求取消，但限定超时
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), explainTimeout)
+	defer cancel()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return "explain failed: " + err.Error()
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	prefix := "EXPLAIN "
+	if dialect == "postgres" {
+		prefix = "EXPLAIN (FORMAT JSON) "
+	}
+
+	rows, err := conn.QueryContext(ctx, prefix+query)
+	if err != nil {
+		return "explain failed: " + err.Error()
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	plan, err := scanPlan(rows)
+	if err != nil {
+		return "explain failed: " + err.Error()
+	}
+	return plan
+}
+
+// scanPlan 读取 EXPLAIN 结果：单列单行直接返回，否则编码为 JSON 数组
+func scanPlan(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var result []map[string]any
+	for rows.Next() {
+		values := make([]sql.RawBytes, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return "", err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if values[i] == nil {
+				row[column] = nil
+			} else {
+				row[column] = string(values[i])
+			}
+		}
+		result = append(result, row)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	// Postgres FORMAT JSON 只返回一列一行
+	if len(columns) == 1 && len(result) == 1 {
+		if plan, ok := result[0][columns[0]].(string); ok {
+			return plan, nil
+		}
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,364 @@
+package internal
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"html/template"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	loger "gorm.io/gorm/logger"
+)
+
+// InspectorConfig 为进程内查询观测器的配置。
+type InspectorConfig struct {
+	// RecentSize 为最近语句环形缓冲区长度，默认 200。
+	RecentSize int
+	// MaxFingerprints 为最多统计的查询形状数量，超过后淘汰最久未出现的形状，默认 1000。
+	MaxFingerprints int
+	// SampleSize 为每个形状用于计算分位数的耗时样本数（蓄水池抽样），默认 512。
+	SampleSize int
+	// Level 为接收的最高日志级别，默认 Info（即全部语句）。
+	Level loger.LogLevel
+}
+
+// InspectorEntry 为一条最近执行的语句。
+type InspectorEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Database    string    `json:"database"`
+	Table       string    `json:"table"`
+	Operation   string    `json:"operation"`
+	Fingerprint string    `json:"fingerprint"`
+	Statement   string    `json:"statement"`
+	Path        string    `json:"path"`
+	TraceId     string    `json:"trace_id"`
+	// Duration 单位为微秒
+	Duration uint64 `json:"duration"`
+	Rows     int64  `json:"rows"`
+	// Error 为失败时的错误信息
+	Error string `json:"error,omitempty"`
+}
+
+// FingerprintStats 为同一查询形状的聚合统计，耗时单位均为微秒。
+type FingerprintStats struct {
+	Fingerprint string `json:"fingerprint"`
+	// Query 为归一化后的 SQL
+	Query     string `json:"query"`
+	Table     string `json:"table"`
+	Operation string `json:"operation"`
+
+	Count  uint64 `json:"count"`
+	Errors uint64 `json:"errors"`
+	Rows   int64  `json:"rows"`
+
+	TotalDuration uint64 `json:"total_duration"`
+	MaxDuration   uint64 `json:"max_duration"`
+	P50           uint64 `json:"p50"`
+	P95           uint64 `json:"p95"`
+	P99           uint64 `json:"p99"`
+
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// fingerprintStat 为 FingerprintStats 的内部累加状态。
+type fingerprintStat struct {
+	FingerprintStats
+	// samples 为蓄水池抽样的耗时样本
+	samples []uint64
+	// element 为该形状在 LRU 链表中的节点
+	element *list.Element
+}
+
+// Inspector 为进程内查询观测器：作为 Sink 接收操作日志，保存最近语句与按指纹聚合的统计，
+// 并实现 http.Handler 输出 JSON / HTML。每个实例独立，不依赖全局状态。
+type Inspector struct {
+	levelFilter
+
+	sampleSize      int
+	maxFingerprints int
+
+	mu     sync.Mutex
+	recent []InspectorEntry
+	// next 为环形缓冲区下一个写入位置，full 表示已写满一轮
+	next  int
+	full  bool
+	stats map[string]*fingerprintStat
+	// lru 按最近出现排序的查询形状，表头为最近出现
+	lru   *list.List
+	since time.Time
+}
+
+// NewInspector 构造查询观测器。
+func NewInspector(config InspectorConfig) *Inspector {
+	if config.RecentSize <= 0 {
+		config.RecentSize = 200
+	}
+	if config.MaxFingerprints <= 0 {
+		config.MaxFingerprints = 1000
+	}
+	if config.SampleSize <= 0 {
+		config.SampleSize = 512
+	}
+	if config.Level == 0 {
+		config.Level = loger.Info
+	}
+
+	return &Inspector{
+		levelFilter:     levelFilter(config.Level),
+		sampleSize:      config.SampleSize,
+		maxFingerprints: config.MaxFingerprints,
+		recent:          make([]InspectorEntry, config.RecentSize),
+		stats:           make(map[string]*fingerprintStat),
+		lru:             list.New(),
+		since:           time.Now(),
+	}
+}
+
+// Emit 记录一条操作日志（系统日志不参与统计）。
+func (i *Inspector) Emit(_ context.Context, level loger.LogLevel, logData *OperationLogger) {
+	if logData == nil || logData.LogType != LogTypeOperation {
+		return
+	}
+
+	entry := InspectorEntry{
+		Timestamp:   logData.Timestamp,
+		Database:    logData.Database,
+		Table:       logData.Table,
+		Operation:   logData.Operation,
+		Fingerprint: logData.Fingerprint,
+		Statement:   logData.Statement,
+		Path:        logData.Path,
+		TraceId:     logData.TraceId,
+		Duration:    logData.Duration,
+		Rows:        logData.Rows,
+	}
+	if level == loger.Error {
+		entry.Error = logData.Result
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.recent[i.next] = entry
+	i.next++
+	if i.next == len(i.recent) {
+		i.next = 0
+		i.full = true
+	}
+
+	stat, ok := i.stats[entry.Fingerprint]
+	if !ok {
+		if len(i.stats) >= i.maxFingerprints {
+			i.evictLocked()
+		}
+		stat = &fingerprintStat{
+			FingerprintStats: FingerprintStats{
+				Fingerprint: entry.Fingerprint,
+				Query:       NormalizeSQL(entry.Statement),
+				Table:       entry.Table,
+				Operation:   entry.Operation,
+			},
+		}
+		stat.element = i.lru.PushFront(stat)
+		i.stats[entry.Fingerprint] = stat
+	} else {
+		i.lru.MoveToFront(stat.element)
+	}
+
+	stat.Count++
+	if entry.Error != "" {
+		stat.Errors++
+	}
+	if entry.Rows > 0 {
+		stat.Rows += entry.Rows
+	}
+	stat.TotalDuration += entry.Duration
+	stat.MaxDuration = max(stat.MaxDuration, entry.Duration)
+	stat.LastSeen = entry.Timestamp
+
+	// 蓄水池抽样，保证每个形状的样本数有上限且均匀
+	if len(stat.samples) < i.sampleSize {
+		stat.samples = append(stat.samples, entry.Duration)
+	} else if j := rand.Uint64N(stat.Count); j < uint64(i.sampleSize) {
+		stat.samples[j] = entry.Duration
+	}
+}
+
+// evictLocked 淘汰最久未出现的查询形状，调用方需持有 mu。
+func (i *Inspector) evictLocked() {
+	if oldest := i.lru.Back(); oldest != nil {
+		i.lru.Remove(oldest)
+		delete(i.stats, oldest.Value.(*fingerprintStat).Fingerprint)
+	}
+}
+
+// Recent 返回最近执行的语句，按时间倒序。
+func (i *Inspector) Recent() []InspectorEntry {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	n := i.next
+	if i.full {
+		n = len(i.recent)
+	}
+	out := make([]InspectorEntry, 0, n)
+	for k := 1; k <= n; k++ {
+		out = append(out, i.recent[(i.next-k+len(i.recent))%len(i.recent)])
+	}
+	return out
+}
+
+// Stats 返回按累计耗时倒序排列的查询形状统计。
+func (i *Inspector) Stats() []FingerprintStats {
+	i.mu.Lock()
+	out := make([]FingerprintStats, 0, len(i.stats))
+	samples := make([][]uint64, 0, len(i.stats))
+	for _, stat := range i.stats {
+		out = append(out, stat.FingerprintStats)
+		samples = append(samples, slices.Clone(stat.samples))
+	}
+	i.mu.Unlock()
+
+	// 分位数在锁外计算，避免阻塞写入
+	for k := range out {
+		slices.Sort(samples[k])
+		out[k].P50 = percentile(samples[k], 0.50)
+		out[k].P95 = percentile(samples[k], 0.95)
+		out[k].P99 = percentile(samples[k], 0.99)
+	}
+
+	slices.SortFunc(out, func(a, b FingerprintStats) int {
+		switch {
+		case a.TotalDuration > b.TotalDuration:
+			return -1
+		case a.TotalDuration < b.TotalDuration:
+			return 1
+		default:
+			return strings.Compare(a.Fingerprint, b.Fingerprint)
+		}
+	})
+	return out
+}
+
+// Reset 清空最近语句与统计。
+func (i *Inspector) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	clear(i.recent)
+	i.next = 0
+	i.full = false
+	i.stats = make(map[string]*fingerprintStat)
+	i.lru.Init()
+	i.since = time.Now()
+}
+
+// percentile 返回已排序样本的 p 分位数（最近秩法）。
+func percentile(sorted []uint64, p float64) uint64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	idx = min(max(idx, 0), len(sorted)-1)
+	return sorted[idx]
+}
+
+// inspectorSnapshot 为 HTTP 输出的数据。
+type inspectorSnapshot struct {
+	Since  time.Time          `json:"since"`
+	Stats  []FingerprintStats `json:"stats"`
+	Recent []InspectorEntry   `json:"recent"`
+}
+
+// ServeHTTP 实现 http.Handler：
+//   - ?format=json 或 Accept: application/json 时输出 JSON，否则输出 HTML；
+//   - ?limit=N 限制统计与最近语句的条数（默认 50）；
+//   - POST ?reset=1 清空统计。
+func (i *Inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("reset") != "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "reset requires POST", http.StatusMethodNotAllowed)
+			return
+		}
+		i.Reset()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	limit := 50
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+
+	i.mu.Lock()
+	since := i.since
+	i.mu.Unlock()
+
+	snapshot := inspectorSnapshot{Since: since, Stats: i.Stats(), Recent: i.Recent()}
+	snapshot.Stats = snapshot.Stats[:min(limit, len(snapshot.Stats))]
+	snapshot.Recent = snapshot.Recent[:min(limit, len(snapshot.Recent))]
+
+	w.Header().Set("Cache-Control", "no-store")
+	if query.Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(snapshot)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = inspectorTemplate.Execute(w, snapshot)
+}
+
+// inspectorTemplate 为 HTML 视图，耗时以毫秒展示。
+var inspectorTemplate = template.Must(template.New("inspector").Funcs(template.FuncMap{
+	"ms": func(us uint64) string {
+		return strconv.FormatFloat(float64(us)/1000, 'f', 3, 64)
+	},
+	"ts": func(t time.Time) string {
+		return t.Format("15:04:05.000")
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gormx inspector</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 16px; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 24px; }
+th, td { border: 1px solid #ddd; padding: 4px 6px; text-align: left; vertical-align: top; }
+th { background: #f5f5f5; }
+td.num { text-align: right; white-space: nowrap; }
+code { white-space: pre-wrap; word-break: break-all; }
+tr.err { background: #fdecea; }
+</style>
+</head>
+<body>
+<h2>Top queries <small>since {{ts .Since}}</small></h2>
+<table>
+<tr><th>Query</th><th>Table</th><th>Count</th><th>Errors</th><th>Rows</th><th>Total ms</th><th>p50 ms</th><th>p95 ms</th><th>p99 ms</th><th>Max ms</th><th>Last seen</th></tr>
+{{range .Stats}}<tr{{if .Errors}} class="err"{{end}}>
+<td><code>{{.Query}}</code><br><small>{{.Fingerprint}}</small></td><td>{{.Table}}</td>
+<td class="num">{{.Count}}</td><td class="num">{{.Errors}}</td><td class="num">{{.Rows}}</td>
+<td class="num">{{ms .TotalDuration}}</td><td class="num">{{ms .P50}}</td><td class="num">{{ms .P95}}</td><td class="num">{{ms .P99}}</td><td class="num">{{ms .MaxDuration}}</td>
+<td>{{ts .LastSeen}}</td>
+</tr>{{end}}
+</table>
+<h2>Recent statements</h2>
+<table>
+<tr><th>Time</th><th>Statement</th><th>Duration ms</th><th>Rows</th><th>Path</th><th>Trace</th></tr>
+{{range .Recent}}<tr{{if .Error}} class="err"{{end}}>
+<td>{{ts .Timestamp}}</td><td><code>{{.Statement}}</code>{{if .Error}}<br><small>{{.Error}}</small>{{end}}</td>
+<td class="num">{{ms .Duration}}</td><td class="num">{{.Rows}}</td><td>{{.Path}}</td><td><small>{{.TraceId}}</small></td>
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
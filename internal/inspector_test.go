@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	loger "gorm.io/gorm/logger"
+)
+
+// emitQuery 以 fingerprint 记录一条语句
+func emitQuery(i *Inspector, fingerprint string) {
+	i.Emit(context.Background(), loger.Info, &OperationLogger{
+		Timestamp:   time.Now(),
+		LogType:     LogTypeOperation,
+		Statement:   "SELECT " + fingerprint,
+		Fingerprint: fingerprint,
+		Duration:    1,
+	})
+}
+
+// fingerprints 返回 Inspector 当前统计的查询形状
+func fingerprints(i *Inspector) map[string]bool {
+	out := make(map[string]bool)
+	for _, stat := range i.Stats() {
+		out[stat.Fingerprint] = true
+	}
+	return out
+}
+
+func TestInspectorEvictsLeastRecentlySeen(t *testing.T) {
+	i := NewInspector(InspectorConfig{MaxFingerprints: 3})
+	emitQuery(i, "a")
+	emitQuery(i, "b")
+	emitQuery(i, "c")
+	// a 再次出现后，最久未出现的是 b
+	emitQuery(i, "a")
+	emitQuery(i, "d")
+
+	got := fingerprints(i)
+	if len(got) != 3 || !got["a"] || got["b"] || !got["c"] || !got["d"] {
+		t.Fatalf("fingerprints = %v", got)
+	}
+	if i.lru.Len() != len(i.stats) {
+		t.Fatalf("lru has %d entries, stats %d", i.lru.Len(), len(i.stats))
+	}
+
+	i.Reset()
+	emitQuery(i, "e")
+	if got = fingerprints(i); len(got) != 1 || i.lru.Len() != 1 {
+		t.Fatalf("after reset: fingerprints = %v, lru = %d", got, i.lru.Len())
+	}
+}
+
+// BenchmarkInspectorEvict 衡量形状数达到上限后每条新形状的淘汰开销
+func BenchmarkInspectorEvict(b *testing.B) {
+	i := NewInspector(InspectorConfig{MaxFingerprints: 1000})
+	for k := 0; k < 1000; k++ {
+		emitQuery(i, fmt.Sprint("warm", k))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		emitQuery(i, fmt.Sprint("q", k))
+	}
+}
@@ -2,34 +2,51 @@ package internal
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"runtime"
-	"strconv"
-	"strings"
 	"time"
 
-	"github.com/fireflycore/go-micro/constant"
 	"go.opentelemetry.io/otel/log"
-	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/trace"
-	"google.golang.org/grpc/metadata"
 	loger "gorm.io/gorm/logger"
 )
 
 const (
 	// ResultSuccess 表示成功执行 SQL 的结果标记
 	ResultSuccess = "success"
+
+	// LogTypeOperation 表示 SQL 执行日志
+	LogTypeOperation = "operation"
+	// LogTypeSystem 表示 gorm 迁移、回调等通过 Info/Warn/Error 输出的系统日志
+	LogTypeSystem = "system"
 )
 
 // OperationLogger 表示操作日志。
 type OperationLogger struct {
+	Timestamp time.Time `json:"timestamp"`
+	// LogType 为日志类型（operation/system）
+	LogType string `json:"log_type"`
+
 	Database  string `json:"database"`
 	Statement string `json:"statement"`
 	Result    string `json:"result"`
 	Path      string `json:"path"`
 
+	// Rows 为影响或返回的行数，-1 表示未知
+	Rows int64 `json:"rows"`
+	// Operation 为语句类型（SELECT/INSERT/UPDATE/DELETE/DDL/OTHER）
+	Operation string `json:"operation"`
+	// Table 为 Statement.Table 对应的主表名
+	Table string `json:"table"`
+	// Fingerprint 为归一化 SQL 的指纹，相同形状的 SQL 指纹相同
+	Fingerprint string `json:"fingerprint"`
+	// ErrorCode 为驱动原生错误码（MySQL 错误号 / Postgres 错误码）
+	ErrorCode string `json:"error_code"`
+	// SqlState 为失败时的 SQLSTATE
+	SqlState string `json:"sql_state"`
+	// Plan 为慢 SQL 的执行计划（开启 ExplainSlowQuery 时）
+	Plan string `json:"plan"`
+
 	Duration uint64 `json:"duration"`
 
 	Level uint32 `json:"level"`
@@ -57,6 +74,18 @@ type Config struct {
 	Database string
 	// 数据库类型
 	DatabaseType uint32
+
+	// Sinks 为结构化日志输出目标，为空时默认上报到 OTel
+	Sinks []Sink
+	// DisableCaller 为 true 时不定位调用方，Path 为空
+	DisableCaller bool
+	// Extractor 从请求上下文中提取调用方信息，为空时读取 gRPC metadata
+	Extractor MetadataExtractor
+
+	// ExplainSlowQuery 为 true 时对慢 SELECT 执行 EXPLAIN 并附加到 warn 日志
+	ExplainSlowQuery bool
+	// ExplainInterval 为同一指纹两次 EXPLAIN 的最小间隔，默认 1 分钟
+	ExplainInterval time.Duration
 }
 
 // NewLogger 构造一个 gorm logger，实现控制台输出与自定义回调输出
@@ -86,6 +115,24 @@ func NewLogger(config Config) loger.Interface {
 		traceErrStr = loger.RedBold + "[%s] " + loger.RedBold + "[error] " + loger.BlueBold + "[Database:%s] " + loger.YellowBold + "[Rows:%v]" + loger.Yellow + " [Duration:%.3fms]" + loger.Green + " [Path:%s]\n" + loger.Red + "%s\n" + loger.Reset + "%s"
 	}
 
+	// 未配置 Sink 时保持默认行为：上报到 OTel
+	sinks := config.Sinks
+	if len(sinks) == 0 {
+		sinks = []Sink{NewOTelSink(loger.Info)}
+	}
+
+	// 未配置 Extractor 时保持默认行为：读取 gRPC metadata
+	extractor := config.Extractor
+	if extractor == nil {
+		extractor = NewGRPCExtractor()
+	}
+
+	// 开启慢 SQL EXPLAIN 时构造 explainer（LogMode 复制的实例共享限流状态）
+	var explain *explainer
+	if config.ExplainSlowQuery {
+		explain = newExplainer(config.ExplainInterval)
+	}
+
 	// 返回实现 loger.Interface 的 logger 实例
 	return &logger{
 		// 复用 gorm logger 配置
@@ -103,6 +150,14 @@ func NewLogger(config Config) loger.Interface {
 		database: config.Database,
 		// databaseType 记录库类型便于聚合检索
 		databaseType: config.DatabaseType,
+		// sinks 为结构化日志输出目标
+		sinks: sinks,
+		// disableCaller 控制是否定位调用方
+		disableCaller: config.DisableCaller,
+		// extractor 提取调用方信息
+		extractor: extractor,
+		// explainer 为慢 SQL 执行 EXPLAIN
+		explainer: explain,
 	}
 }
 
@@ -120,6 +175,14 @@ type logger struct {
 	databaseType uint32
 	// console 控制台输出开关
 	console bool
+	// sinks 为结构化日志输出目标
+	sinks []Sink
+	// disableCaller 为 true 时跳过调用方定位
+	disableCaller bool
+	// extractor 从请求上下文中提取调用方信息
+	extractor MetadataExtractor
+	// explainer 为慢 SQL 执行 EXPLAIN，为 nil 时不执行
+	explainer *explainer
 }
 
 // LogMode 设置日志级别，返回一个新的 logger（符合 gorm 约定）
@@ -132,41 +195,52 @@ func (l *logger) LogMode(level loger.LogLevel) loger.Interface {
 	return &newLogger
 }
 
-// Info 输出 info 日志（受 LogLevel 与 console 开关控制）
-func (l *logger) Info(_ context.Context, msg string, data ...interface{}) {
-	// 仅当启用控制台输出且 LogLevel 允许时才输出
-	if l.console && l.LogLevel >= loger.Info {
-		date := time.Now().Format(time.DateTime)
-		file := fileWithLineNum()
-		// msg 和 data 组合成完整消息
-		fullMsg := fmt.Sprintf(msg, data...)
-		// 输出到标准输出: date, db, file, msg
-		fmt.Printf(l.infoStr+"\n", date, l.database, file, fullMsg)
+// Info 输出 info 日志（控制台受 console 开关控制，结构化日志交给各 Sink）
+func (l *logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.LogLevel >= loger.Info {
+		l.logSystem(ctx, loger.Info, l.infoStr, msg, data...)
 	}
 }
 
-// Warn 输出 warn 日志（受 LogLevel 与 console 开关控制）
-func (l *logger) Warn(_ context.Context, msg string, data ...interface{}) {
-	// 仅当启用控制台输出且 LogLevel 允许时才输出
-	if l.console && l.LogLevel >= loger.Warn {
-		date := time.Now().Format(time.DateTime)
-		file := fileWithLineNum()
-		fullMsg := fmt.Sprintf(msg, data...)
-		// 输出到标准输出: date, db, file, msg
-		fmt.Printf(l.warnStr+"\n", date, l.database, file, fullMsg)
+// Warn 输出 warn 日志（控制台受 console 开关控制，结构化日志交给各 Sink）
+func (l *logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.LogLevel >= loger.Warn {
+		l.logSystem(ctx, loger.Warn, l.warnStr, msg, data...)
+	}
+}
+
+// Error 输出 error 日志（控制台受 console 开关控制，结构化日志交给各 Sink）
+func (l *logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.LogLevel >= loger.Error {
+		l.logSystem(ctx, loger.Error, l.errStr, msg, data...)
 	}
 }
 
-// Error 输出 error 日志（受 LogLevel 与 console 开关控制）
-func (l *logger) Error(_ context.Context, msg string, data ...interface{}) {
-	// 仅当启用控制台输出且 LogLevel 允许时才输出
-	if l.console && l.LogLevel >= loger.Error {
+// logSystem 处理 gorm 的迁移、回调等系统日志：按模板输出控制台并以 log_type=system 交给各 Sink
+func (l *logger) logSystem(ctx context.Context, level loger.LogLevel, tmpl, msg string, data ...interface{}) {
+	file := l.fileWithLineNum()
+	// msg 和 data 组合成完整消息
+	fullMsg := fmt.Sprintf(msg, data...)
+
+	// 控制台输出（若开启）: date, db, file, msg
+	if l.console {
 		date := time.Now().Format(time.DateTime)
-		file := fileWithLineNum()
-		fullMsg := fmt.Sprintf(msg, data...)
-		// 输出到标准输出: date, db, file, msg
-		fmt.Printf(l.errStr+"\n", date, l.database, file, fullMsg)
+		fmt.Printf(tmpl+"\n", date, l.database, file, fullMsg)
+	}
+
+	logData := &OperationLogger{
+		Timestamp: time.Now(),
+		LogType:   LogTypeSystem,
+		Database:  l.database,
+		Result:    fullMsg,
+		Path:      file,
+
+		Rows: -1,
+
+		Level: levelConvertValue(level),
+		Type:  l.databaseType,
 	}
+	l.emit(ctx, level, logData)
 }
 
 // Trace 记录 SQL 执行信息（成功/慢 SQL/错误）
@@ -186,11 +260,11 @@ func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 		// timer 为耗时的毫秒值（浮点便于输出 3 位小数）
 		timer := float64(elapsed.Nanoseconds()) / 1e6
 		// file 为调用位置
-		file := fileWithLineNum()
-		date := time.Now().Format(time.DateTime)
+		file := l.fileWithLineNum()
 
 		// 控制台输出（若开启）
 		if l.console {
+			date := time.Now().Format(time.DateTime)
 			rowsStr := "-"
 			if rows != -1 {
 				rowsStr = fmt.Sprintf("%v", rows)
@@ -199,7 +273,7 @@ func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 			fmt.Printf(l.traceErrStr+"\n", date, l.database, rowsStr, timer, file, err, sql)
 		}
 		// 结构化回调输出
-		l.handleLog(ctx, loger.Error, file, sql, err.Error(), elapsed)
+		l.handleLog(ctx, loger.Error, file, sql, err.Error(), elapsed, rows, err)
 
 	case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= loger.Warn:
 		// 从回调取出 SQL 文本与影响行数
@@ -209,11 +283,11 @@ func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 		// timer 为耗时的毫秒值（浮点便于输出 3 位小数）
 		timer := float64(elapsed.Nanoseconds()) / 1e6
 		// file 为调用位置
-		file := fileWithLineNum()
-		date := time.Now().Format(time.DateTime)
+		file := l.fileWithLineNum()
 
 		// 控制台输出（若开启）
 		if l.console {
+			date := time.Now().Format(time.DateTime)
 			rowsStr := "-"
 			if rows != -1 {
 				rowsStr = fmt.Sprintf("%v", rows)
@@ -222,7 +296,7 @@ func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 			fmt.Printf(l.traceWarnStr+"\n", date, l.database, rowsStr, timer, file, slowLog, sql)
 		}
 		// 结构化回调输出
-		l.handleLog(ctx, loger.Warn, file, sql, slowLog, elapsed)
+		l.handleLog(ctx, loger.Warn, file, sql, slowLog, elapsed, rows, nil)
 
 	case l.LogLevel == loger.Info:
 		// 从回调取出 SQL 文本与影响行数
@@ -230,11 +304,11 @@ func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 		// timer 为耗时的毫秒值（浮点便于输出 3 位小数）
 		timer := float64(elapsed.Nanoseconds()) / 1e6
 		// file 为调用位置
-		file := fileWithLineNum()
-		date := time.Now().Format(time.DateTime)
+		file := l.fileWithLineNum()
 
 		// 控制台输出（若开启）
 		if l.console {
+			date := time.Now().Format(time.DateTime)
 			rowsStr := "-"
 			if rows != -1 {
 				rowsStr = fmt.Sprintf("%v", rows)
@@ -243,97 +317,85 @@ func (l *logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 			fmt.Printf(l.traceStr+"\n", date, l.database, rowsStr, timer, file, sql)
 		}
 		// 结构化回调输出（成功分支）
-		l.handleLog(ctx, loger.Info, file, sql, ResultSuccess, elapsed)
+		l.handleLog(ctx, loger.Info, file, sql, ResultSuccess, elapsed, rows, nil)
 	}
 }
 
-// handleLog 将日志以 JSON 形式写入回调（若提供）
-func (l *logger) handleLog(ctx context.Context, level loger.LogLevel, path, smt, result string, elapsed time.Duration) {
+// handleLog 构造结构化日志并交给各 Sink 输出
+func (l *logger) handleLog(ctx context.Context, level loger.LogLevel, path, smt, result string, elapsed time.Duration, rows int64, err error) {
 	// log 为结构化日志内容，字段名保持相对稳定便于下游解析
 	logData := &OperationLogger{
+		Timestamp: time.Now(),
+		LogType:   LogTypeOperation,
 		Database:  l.database,
 		Statement: smt,
 		Result:    result,
 		Path:      path,
 
+		Rows:      rows,
+		Operation: StatementOperation(smt),
+
 		Duration: uint64(elapsed.Microseconds()),
 
 		Level: levelConvertValue(level),
 		Type:  l.databaseType,
 	}
 
-	// 从 OTel span context 中提取链路字段（优先）
-	spanCtx := trace.SpanFromContext(ctx).SpanContext()
-	if spanCtx.IsValid() {
-		logData.TraceId = spanCtx.TraceID().String()
-		logData.ParentId = spanCtx.SpanID().String()
-	}
-
-	// 从 gRPC metadata 中提取链路字段（存在则写入结构化日志，作为兼容兜底）
-	md, _ := metadata.FromIncomingContext(ctx)
-	if gd := md.Get(constant.UserId); len(gd) != 0 {
-		logData.UserId = gd[0]
-	}
-	if gd := md.Get(constant.AppId); len(gd) != 0 {
-		logData.AppId = gd[0]
-	}
-	if gd := md.Get(constant.InvokeServiceAppId); len(gd) != 0 {
-		logData.InvokeAppId = gd[0]
-	}
-	if gd := md.Get(constant.TargetServiceAppId); len(gd) != 0 {
-		logData.TargetAppId = gd[0]
+	// 主表名来自 StatementPlugin 注入的 Statement；指纹优先基于带占位符的 SQL 计算，缓存命中率更高
+	fingerprintSource := smt
+	stmt := StatementFromContext(ctx)
+	if stmt != nil {
+		logData.Table = stmt.Table
+		if stmt.SQL.Len() != 0 {
+			fingerprintSource = stmt.SQL.String()
+		}
 	}
-	if gd := md.Get(constant.TenantId); len(gd) != 0 {
-		logData.TenantId = gd[0]
+	logData.Fingerprint = SQLFingerprint(fingerprintSource)
+	// 失败时记录驱动原生错误码
+	logData.ErrorCode, logData.SqlState = ErrorCode(err)
+
+	// 慢 SQL 按指纹限流执行 EXPLAIN，在独立连接上异步完成后再输出，不阻塞当前请求
+	if level == loger.Warn && l.explainer != nil && stmt != nil && l.explainer.allow(logData.Fingerprint, logData.Operation, smt) {
+		if sqlDB, dbErr := stmt.DB.DB(); dbErr == nil {
+			dialect := stmt.DB.Dialector.Name()
+			go func() {
+				logData.Plan = l.explainer.explain(ctx, sqlDB, dialect, smt)
+				l.emit(ctx, level, logData)
+			}()
+			return
+		}
 	}
 
-	l.emitOTelOperationLog(ctx, level, logData)
+	l.emit(ctx, level, logData)
 }
 
-func (l *logger) emitOTelOperationLog(ctx context.Context, level loger.LogLevel, logData *OperationLogger) {
-	if logData == nil {
-		return
+// emit 补齐链路与调用方字段后交给各 Sink 输出
+func (l *logger) emit(ctx context.Context, level loger.LogLevel, logData *OperationLogger) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	otelLogger := global.Logger("gormx")
-
-	var record log.Record
-	record.SetTimestamp(time.Now())
-	record.SetSeverity(convertOTelSeverity(level))
-	record.SetSeverityText(convertOTelSeverityText(level))
-
-	if b, err := json.Marshal(logData); err == nil {
-		record.SetBody(log.StringValue(string(b)))
-	} else {
-		record.SetBody(log.StringValue(logData.Statement))
+	// 从 OTel span context 中提取链路字段（优先）
+	spanCtx := trace.SpanFromContext(ctx).SpanContext()
+	if spanCtx.IsValid() {
+		logData.TraceId = spanCtx.TraceID().String()
+		logData.ParentId = spanCtx.SpanID().String()
 	}
 
-	record.AddAttributes(
-		log.String("log_type", "operation"),
-		log.String("database", logData.Database),
-		log.String("statement", logData.Statement),
-		log.String("result", logData.Result),
-		log.String("path", logData.Path),
-		log.Int64("duration", int64(logData.Duration)),
-		log.Int64("db_type", int64(logData.Type)),
-	)
-	if logData.UserId != "" {
-		record.AddAttributes(log.String("user_id", logData.UserId))
-	}
-	if logData.AppId != "" {
-		record.AddAttributes(log.String("app_id", logData.AppId))
-	}
-	if logData.InvokeAppId != "" {
-		record.AddAttributes(log.String("invoke_app_id", logData.InvokeAppId))
-	}
-	if logData.TargetAppId != "" {
-		record.AddAttributes(log.String("target_app_id", logData.TargetAppId))
-	}
-	if logData.TenantId != "" {
-		record.AddAttributes(log.String("tenant_id", logData.TenantId))
+	// 通过 MetadataExtractor 提取调用方字段（默认读取 gRPC metadata）
+	md := l.extractor.Extract(ctx)
+	logData.UserId = md.UserId
+	logData.AppId = md.AppId
+	logData.InvokeAppId = md.InvokeAppId
+	logData.TargetAppId = md.TargetAppId
+	logData.TenantId = md.TenantId
+
+	// 依次交给各 Sink 输出
+	for _, sink := range l.sinks {
+		if sink.Enabled(level) {
+			sink.Emit(ctx, level, logData)
+		}
 	}
-
-	otelLogger.Emit(ctx, record)
 }
 
 func convertOTelSeverity(level loger.LogLevel) log.Severity {
@@ -362,14 +424,12 @@ func convertOTelSeverityText(level loger.LogLevel) string {
 	}
 }
 
-func fileWithLineNum() string {
-	for i := 2; i < 15; i++ {
-		_, file, line, ok := runtime.Caller(i)
-		if ok && (!strings.Contains(file, "gorm.io/gorm") || strings.HasSuffix(file, "_test.go")) && !strings.Contains(file, "gormx/internal/logger.go") {
-			return file + ":" + strconv.FormatInt(int64(line), 10)
-		}
+// fileWithLineNum 返回调用方位置，关闭调用方定位时返回空字符串
+func (l *logger) fileWithLineNum() string {
+	if l.disableCaller {
+		return ""
 	}
-	return ""
+	return Caller()
 }
 
 func levelConvertValue(level loger.LogLevel) uint32 {
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+	loger "gorm.io/gorm/logger"
+)
+
+// discardSink 丢弃所有日志，仅用于衡量 logger 自身开销
+type discardSink struct{}
+
+func (discardSink) Enabled(loger.LogLevel) bool { return true }
+
+func (discardSink) Emit(context.Context, loger.LogLevel, *OperationLogger) {}
+
+// benchmarkContext 构造带有 span 与 gRPC metadata 的请求上下文
+func benchmarkContext() context.Context {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "u1", "tenant-id", "t1"))
+	return trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+// benchmarkTrace 以 Info 级别反复记录同一条 SQL
+func benchmarkTrace(b *testing.B, config Config) {
+	config.Config = loger.Config{SlowThreshold: time.Second, LogLevel: loger.Info}
+	l := NewLogger(config)
+	ctx := benchmarkContext()
+	fc := func() (string, int64) {
+		return "SELECT * FROM `users` WHERE `users`.`id` = 42 AND `users`.`deleted_at` = 0 LIMIT 1", 1
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Trace(ctx, time.Now(), fc, nil)
+	}
+}
+
+// BenchmarkTraceDiscardSink 衡量单条 SQL 在 logger 内部（含调用方定位）的开销
+func BenchmarkTraceDiscardSink(b *testing.B) {
+	benchmarkTrace(b, Config{Database: "demo", Sinks: []Sink{discardSink{}}})
+}
+
+// BenchmarkTraceOTelSink 衡量单条 SQL 经默认 OTel Sink 上报的开销
+func BenchmarkTraceOTelSink(b *testing.B) {
+	benchmarkTrace(b, Config{Database: "demo"})
+}
+
+// BenchmarkTraceNoCaller 衡量关闭调用方定位后的开销
+func BenchmarkTraceNoCaller(b *testing.B) {
+	benchmarkTrace(b, Config{Database: "demo", Sinks: []Sink{discardSink{}}, DisableCaller: true})
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/fireflycore/go-micro/constant"
+	"go.opentelemetry.io/otel/baggage"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata 为从请求上下文中提取的调用方信息
+type Metadata struct {
+	UserId      string `json:"user_id"`
+	AppId       string `json:"app_id"`
+	TenantId    string `json:"tenant_id"`
+	InvokeAppId string `json:"invoke_app_id"`
+	TargetAppId string `json:"target_app_id"`
+}
+
+// merge 用 other 补齐当前为空的字段
+func (m *Metadata) merge(other Metadata) {
+	if m.UserId == "" {
+		m.UserId = other.UserId
+	}
+	if m.AppId == "" {
+		m.AppId = other.AppId
+	}
+	if m.TenantId == "" {
+		m.TenantId = other.TenantId
+	}
+	if m.InvokeAppId == "" {
+		m.InvokeAppId = other.InvokeAppId
+	}
+	if m.TargetAppId == "" {
+		m.TargetAppId = other.TargetAppId
+	}
+}
+
+// complete 判断所有字段是否均已提取
+func (m *Metadata) complete() bool {
+	return m.UserId != "" && m.AppId != "" && m.TenantId != "" && m.InvokeAppId != "" && m.TargetAppId != ""
+}
+
+// MetadataExtractor 从请求上下文中提取调用方信息
+type MetadataExtractor interface {
+	Extract(ctx context.Context) Metadata
+}
+
+// MetadataExtractorFunc 为函数形式的 MetadataExtractor
+type MetadataExtractorFunc func(ctx context.Context) Metadata
+
+// Extract 实现 MetadataExtractor
+func (f MetadataExtractorFunc) Extract(ctx context.Context) Metadata {
+	return f(ctx)
+}
+
+// grpcExtractor 从 gRPC incoming metadata 中提取（go-micro 约定的 key）
+type grpcExtractor struct{}
+
+// NewGRPCExtractor 构造读取 gRPC incoming metadata 的 MetadataExtractor（默认行为）
+func NewGRPCExtractor() MetadataExtractor {
+	return grpcExtractor{}
+}
+
+// Extract 实现 MetadataExtractor
+func (grpcExtractor) Extract(ctx context.Context) Metadata {
+	return Metadata{
+		UserId:      firstIncoming(ctx, constant.UserId),
+		AppId:       firstIncoming(ctx, constant.AppId),
+		TenantId:    firstIncoming(ctx, constant.TenantId),
+		InvokeAppId: firstIncoming(ctx, constant.InvokeServiceAppId),
+		TargetAppId: firstIncoming(ctx, constant.TargetServiceAppId),
+	}
+}
+
+// firstIncoming 读取 gRPC incoming metadata 中 key 的第一个值
+func firstIncoming(ctx context.Context, key string) string {
+	if gd := metadata.ValueFromIncomingContext(ctx, key); len(gd) != 0 {
+		return gd[0]
+	}
+	return ""
+}
+
+// baggageExtractor 从 OTel baggage 中提取
+type baggageExtractor struct{}
+
+// NewBaggageExtractor 构造读取 OTel baggage 的 MetadataExtractor，成员名与 go-micro 的 metadata key 一致
+func NewBaggageExtractor() MetadataExtractor {
+	return baggageExtractor{}
+}
+
+// Extract 实现 MetadataExtractor
+func (baggageExtractor) Extract(ctx context.Context) Metadata {
+	bag := baggage.FromContext(ctx)
+	if bag.Len() == 0 {
+		return Metadata{}
+	}
+	return Metadata{
+		UserId:      bag.Member(constant.UserId).Value(),
+		AppId:       bag.Member(constant.AppId).Value(),
+		TenantId:    bag.Member(constant.TenantId).Value(),
+		InvokeAppId: bag.Member(constant.InvokeServiceAppId).Value(),
+		TargetAppId: bag.Member(constant.TargetServiceAppId).Value(),
+	}
+}
+
+// ContextKeys 为业务自定义的 context key，值需为 string；为 nil 的 key 不提取
+type ContextKeys struct {
+	UserId      any
+	AppId       any
+	TenantId    any
+	InvokeAppId any
+	TargetAppId any
+}
+
+// contextKeyExtractor 按 ContextKeys 从 context value 中提取
+type contextKeyExtractor struct {
+	keys ContextKeys
+}
+
+// NewContextKeyExtractor 构造按自定义 context key 提取的 MetadataExtractor
+func NewContextKeyExtractor(keys ContextKeys) MetadataExtractor {
+	return contextKeyExtractor{keys: keys}
+}
+
+// Extract 实现 MetadataExtractor
+func (e contextKeyExtractor) Extract(ctx context.Context) Metadata {
+	return Metadata{
+		UserId:      contextString(ctx, e.keys.UserId),
+		AppId:       contextString(ctx, e.keys.AppId),
+		TenantId:    contextString(ctx, e.keys.TenantId),
+		InvokeAppId: contextString(ctx, e.keys.InvokeAppId),
+		TargetAppId: contextString(ctx, e.keys.TargetAppId),
+	}
+}
+
+// contextString 读取 ctx 中 key 对应的 string 值
+func contextString(ctx context.Context, key any) string {
+	if key == nil {
+		return ""
+	}
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+// metadataKey 为 WithMetadata 写入 context 时使用的 key
+type metadataKey struct{}
+
+// WithMetadata 将 Metadata 写入 context，供 NewContextExtractor 读取（适用于 HTTP 与后台任务）
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// contextExtractor 读取 WithMetadata 写入的 Metadata
+type contextExtractor struct{}
+
+// NewContextExtractor 构造读取 WithMetadata 写入值的 MetadataExtractor
+func NewContextExtractor() MetadataExtractor {
+	return contextExtractor{}
+}
+
+// Extract 实现 MetadataExtractor
+func (contextExtractor) Extract(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// chainExtractor 依次调用多个 MetadataExtractor，靠前者优先
+type chainExtractor []MetadataExtractor
+
+// NewChainExtractor 组合多个 MetadataExtractor，每个字段取第一个非空值
+func NewChainExtractor(extractors ...MetadataExtractor) MetadataExtractor {
+	return chainExtractor(extractors)
+}
+
+// Extract 实现 MetadataExtractor
+func (c chainExtractor) Extract(ctx context.Context) Metadata {
+	var md Metadata
+	for _, extractor := range c {
+		if extractor == nil {
+			continue
+		}
+		md.merge(extractor.Extract(ctx))
+		if md.complete() {
+			break
+		}
+	}
+	return md
+}
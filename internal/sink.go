@@ -0,0 +1,343 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"gorm.io/gorm"
+	loger "gorm.io/gorm/logger"
+)
+
+// Sink 为操作日志的输出目标，logger 会把每条结构化日志依次交给所有 Sink。
+type Sink interface {
+	// Enabled 判断该级别的日志是否需要交给此 Sink。
+	Enabled(level loger.LogLevel) bool
+	// Emit 输出一条操作日志，实现方不应修改 record。
+	Emit(ctx context.Context, level loger.LogLevel, record *OperationLogger)
+}
+
+// levelFilter 为各内置 Sink 共用的级别过滤，Error < Warn < Info。
+type levelFilter loger.LogLevel
+
+// Enabled 当日志级别不高于配置级别时返回 true。
+func (f levelFilter) Enabled(level loger.LogLevel) bool {
+	return level > loger.Silent && level <= loger.LogLevel(f)
+}
+
+// otelSink 通过 OTel Logs SDK 上报操作日志。
+type otelSink struct {
+	levelFilter
+	// logger 在构造时获取一次；全局 Provider 晚于此处注册时会原地生效
+	logger log.Logger
+}
+
+// NewOTelSink 构造上报到全局 OTel LoggerProvider 的 Sink。
+func NewOTelSink(level loger.LogLevel) Sink {
+	return &otelSink{levelFilter: levelFilter(level), logger: global.Logger("gormx")}
+}
+
+// Emit 将操作日志转换为 OTel log.Record 并上报。
+func (s *otelSink) Emit(ctx context.Context, level loger.LogLevel, logData *OperationLogger) {
+	if logData == nil {
+		return
+	}
+
+	var record log.Record
+	record.SetTimestamp(logData.Timestamp)
+	record.SetSeverity(convertOTelSeverity(level))
+	record.SetSeverityText(convertOTelSeverityText(level))
+
+	// Body 仅放 SQL（系统日志为消息文本），其余字段只以属性形式上报，避免重复编码
+	if logData.Statement != "" {
+		record.SetBody(log.StringValue(logData.Statement))
+	} else {
+		record.SetBody(log.StringValue(logData.Result))
+	}
+
+	record.AddAttributes(
+		log.String("log_type", logData.LogType),
+		log.String("database", logData.Database),
+		log.String("result", logData.Result),
+		log.String("path", logData.Path),
+		log.Int64("rows", logData.Rows),
+		log.String("operation", logData.Operation),
+		log.String("table", logData.Table),
+		log.String("fingerprint", logData.Fingerprint),
+		log.Int64("duration", int64(logData.Duration)),
+		log.Int64("db_type", int64(logData.Type)),
+	)
+	if logData.ErrorCode != "" {
+		record.AddAttributes(log.String("error_code", logData.ErrorCode))
+	}
+	if logData.SqlState != "" {
+		record.AddAttributes(log.String("sql_state", logData.SqlState))
+	}
+	if logData.Plan != "" {
+		record.AddAttributes(log.String("plan", logData.Plan))
+	}
+	if logData.UserId != "" {
+		record.AddAttributes(log.String("user_id", logData.UserId))
+	}
+	if logData.AppId != "" {
+		record.AddAttributes(log.String("app_id", logData.AppId))
+	}
+	if logData.InvokeAppId != "" {
+		record.AddAttributes(log.String("invoke_app_id", logData.InvokeAppId))
+	}
+	if logData.TargetAppId != "" {
+		record.AddAttributes(log.String("target_app_id", logData.TargetAppId))
+	}
+	if logData.TenantId != "" {
+		record.AddAttributes(log.String("tenant_id", logData.TenantId))
+	}
+
+	s.logger.Emit(ctx, record)
+}
+
+// slogSink 将操作日志转交给 slog.Handler。
+type slogSink struct {
+	levelFilter
+	handler slog.Handler
+}
+
+// NewSlogSink 构造输出到 slog.Handler 的 Sink。
+func NewSlogSink(handler slog.Handler, level loger.LogLevel) Sink {
+	return &slogSink{levelFilter: levelFilter(level), handler: handler}
+}
+
+// Emit 将操作日志转换为 slog.Record 并交给 handler。
+func (s *slogSink) Emit(ctx context.Context, level loger.LogLevel, logData *OperationLogger) {
+	if logData == nil || s.handler == nil {
+		return
+	}
+
+	slogLevel := convertSlogLevel(level)
+	// handler 自身也可能带有级别过滤。
+	if !s.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	record := slog.NewRecord(logData.Timestamp, slogLevel, logData.Result, 0)
+	record.AddAttrs(
+		slog.String("log_type", logData.LogType),
+		slog.String("database", logData.Database),
+		slog.String("statement", logData.Statement),
+		slog.String("result", logData.Result),
+		slog.String("path", logData.Path),
+		slog.Int64("rows", logData.Rows),
+		slog.String("operation", logData.Operation),
+		slog.String("table", logData.Table),
+		slog.String("fingerprint", logData.Fingerprint),
+		slog.String("error_code", logData.ErrorCode),
+		slog.String("sql_state", logData.SqlState),
+		slog.String("plan", logData.Plan),
+		slog.Uint64("duration", logData.Duration),
+		slog.Uint64("db_type", uint64(logData.Type)),
+		slog.String("trace_id", logData.TraceId),
+		slog.String("parent_id", logData.ParentId),
+		slog.String("user_id", logData.UserId),
+		slog.String("app_id", logData.AppId),
+		slog.String("invoke_app_id", logData.InvokeAppId),
+		slog.String("target_app_id", logData.TargetAppId),
+		slog.String("tenant_id", logData.TenantId),
+	)
+
+	_ = s.handler.Handle(ctx, record)
+}
+
+// jsonSink 将操作日志以 JSON Lines 形式写入 io.Writer。
+type jsonSink struct {
+	levelFilter
+	// mu 保证多协程写入时每行完整。
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONSink 构造以 JSON Lines 写入 w 的 Sink。
+func NewJSONSink(w io.Writer, level loger.LogLevel) Sink {
+	return &jsonSink{levelFilter: levelFilter(level), w: w}
+}
+
+// Emit 将操作日志编码为一行 JSON 并写入。
+func (s *jsonSink) Emit(_ context.Context, _ loger.LogLevel, logData *OperationLogger) {
+	if logData == nil || s.w == nil {
+		return
+	}
+
+	b, err := json.Marshal(logData)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	_, _ = s.w.Write(b)
+	s.mu.Unlock()
+}
+
+// TableSinkConfig 为数据库表 Sink 的配置。
+type TableSinkConfig struct {
+	// Table 为写入的表名，默认 gormx_operation_log。
+	Table string
+	// Level 为该 Sink 接收的最高日志级别，默认 Info。
+	Level loger.LogLevel
+	// BufferSize 为异步缓冲区长度，缓冲区满时新日志会被丢弃，默认 1024。
+	BufferSize int
+	// BatchSize 为单次批量写入的条数，默认 100。
+	BatchSize int
+	// FlushInterval 为最长刷新间隔，默认 1 秒。
+	FlushInterval time.Duration
+	// AutoMigrate 为 true 时在构造时自动建表。
+	AutoMigrate bool
+}
+
+// TableSink 将操作日志异步批量写入数据库表。
+type TableSink struct {
+	levelFilter
+	db    *gorm.DB
+	table string
+
+	batchSize     int
+	flushInterval time.Duration
+
+	// mu 保护 closed，避免 Close 之后继续向 records 写入。
+	mu      sync.RWMutex
+	closed  bool
+	records chan OperationLogger
+	done    chan struct{}
+
+	// dropped 为缓冲区满时丢弃的日志条数。
+	dropped atomic.Uint64
+}
+
+// NewTableSink 构造写入数据库表的 Sink，并启动后台写入协程。
+func NewTableSink(db *gorm.DB, config TableSinkConfig) (*TableSink, error) {
+	if config.Table == "" {
+		config.Table = "gormx_operation_log"
+	}
+	if config.Level == 0 {
+		config.Level = loger.Info
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1024
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	// 写日志用的会话必须丢弃自身日志，否则会递归产生操作日志。
+	db = db.Session(&gorm.Session{NewDB: true, Logger: loger.Discard})
+
+	if config.AutoMigrate {
+		if err := db.Table(config.Table).AutoMigrate(&OperationLogger{}); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &TableSink{
+		levelFilter:   levelFilter(config.Level),
+		db:            db,
+		table:         config.Table,
+		batchSize:     config.BatchSize,
+		flushInterval: config.FlushInterval,
+		records:       make(chan OperationLogger, config.BufferSize),
+		done:          make(chan struct{}),
+	}
+	go s.run()
+
+	return s, nil
+}
+
+// Emit 将操作日志放入缓冲区，缓冲区满时直接丢弃，不阻塞 SQL 执行。
+func (s *TableSink) Emit(_ context.Context, _ loger.LogLevel, logData *OperationLogger) {
+	if logData == nil {
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.records <- *logData:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped 返回因缓冲区满而被丢弃的日志条数。
+func (s *TableSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close 停止接收日志，并等待缓冲区内的日志全部写入。
+func (s *TableSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.records)
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// run 为后台写入协程，按 BatchSize 或 FlushInterval 批量落库。
+func (s *TableSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]OperationLogger, 0, s.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		_ = s.db.Table(s.table).CreateInBatches(&batch, s.batchSize).Error
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case record, ok := <-s.records:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// convertSlogLevel 将 gorm 日志级别转换为 slog.Level。
+func convertSlogLevel(level loger.LogLevel) slog.Level {
+	switch level {
+	case loger.Error:
+		return slog.LevelError
+	case loger.Warn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
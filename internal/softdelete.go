@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SoftDeleteMode 为软删除字段的取值方式
+type SoftDeleteMode int
+
+const (
+	// SoftDeleteSecond 为 soft_delete.DeletedAt 默认的 unix 秒，未删除时为 0
+	SoftDeleteSecond SoftDeleteMode = iota
+	// SoftDeleteMilli 为 unix 毫秒（gorm:"softDelete:milli"），未删除时为 0
+	SoftDeleteMilli
+	// SoftDeleteNano 为 unix 纳秒（gorm:"softDelete:nano"），未删除时为 0
+	SoftDeleteNano
+	// SoftDeleteFlag 为 0/1 标记（gorm:"softDelete:flag"），删除时间可由 DeletedAtField 记录
+	SoftDeleteFlag
+	// SoftDeleteTime 为 gorm.DeletedAt，未删除时为 NULL
+	SoftDeleteTime
+)
+
+// ErrNoDeletionTime 表示 flag 模式的软删除字段没有配置 DeletedAtField，无法按删除时间过滤
+var ErrNoDeletionTime = errors.New("soft delete flag has no DeletedAtField")
+
+// SoftDelete 为模型的软删除字段及其取值方式
+type SoftDelete struct {
+	Field *schema.Field
+	Mode  SoftDeleteMode
+	// DeletedAtField 为 flag 模式下记录删除时间的字段（gorm:"softDelete:flag,DeletedAtField:DeletedAt"），可为 nil
+	DeletedAtField *schema.Field
+	// DeletedAtUnit 为 DeletedAtField 为整数时的时间单位
+	DeletedAtUnit schema.TimeType
+}
+
+// SoftDeleteField 返回模型的软删除字段（gorm.DeletedAt、soft_delete.DeletedAt 等实现了 DeleteClausesInterface 的字段），没有时返回 nil
+func SoftDeleteField(s *schema.Schema) *schema.Field {
+	if s == nil {
+		return nil
+	}
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if _, ok := reflect.New(field.IndirectFieldType).Interface().(schema.DeleteClausesInterface); ok {
+			return field
+		}
+	}
+	return nil
+}
+
+// ParseSoftDelete 解析模型的软删除字段与取值方式，与 soft_delete 插件对 softDelete 标签的解析一致
+func ParseSoftDelete(s *schema.Schema) (SoftDelete, bool) {
+	field := SoftDeleteField(s)
+	if field == nil {
+		return SoftDelete{}, false
+	}
+	if field.IndirectFieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+		return SoftDelete{Field: field, Mode: SoftDeleteTime}, true
+	}
+
+	sd := SoftDelete{Field: field, Mode: SoftDeleteSecond, DeletedAtUnit: schema.UnixSecond}
+	settings := schema.ParseTagSetting(field.TagSettings["SOFTDELETE"], ",")
+	switch {
+	case settings["NANO"] != "" || settings["DELETEDATFIELDUNIT"] == "nano":
+		sd.Mode, sd.DeletedAtUnit = SoftDeleteNano, schema.UnixNanosecond
+	case settings["MILLI"] != "" || settings["DELETEDATFIELDUNIT"] == "milli":
+		sd.Mode, sd.DeletedAtUnit = SoftDeleteMilli, schema.UnixMillisecond
+	}
+	if settings["FLAG"] != "" {
+		sd.Mode = SoftDeleteFlag
+	}
+	if name := settings["DELETEDATFIELD"]; name != "" {
+		sd.DeletedAtField = s.LookUpField(name)
+	}
+	return sd, true
+}
+
+// column 返回软删除字段所在的列
+func (sd SoftDelete) column() clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName}
+}
+
+// Trashed 返回“已删除”条件
+func (sd SoftDelete) Trashed() clause.Expression {
+	if sd.Mode == SoftDeleteTime {
+		return clause.Neq{Column: sd.column(), Value: nil}
+	}
+	return clause.Neq{Column: sd.column(), Value: 0}
+}
+
+// ActiveValue 返回未删除时软删除字段的取值
+func (sd SoftDelete) ActiveValue() interface{} {
+	if sd.Mode == SoftDeleteTime {
+		return nil
+	}
+	return 0
+}
+
+// DeletedBefore 返回“在 t 之前删除”的条件；flag 模式需要 DeletedAtField 记录删除时间
+func (sd SoftDelete) DeletedBefore(t time.Time) (clause.Expression, error) {
+	switch sd.Mode {
+	case SoftDeleteTime:
+		return clause.Lt{Column: sd.column(), Value: t}, nil
+	case SoftDeleteFlag:
+		if sd.DeletedAtField == nil {
+			return nil, ErrNoDeletionTime
+		}
+		column := clause.Column{Table: clause.CurrentTable, Name: sd.DeletedAtField.DBName}
+		if sd.DeletedAtField.GORMDataType == schema.Time {
+			return clause.And(sd.Trashed(), clause.Lt{Column: column, Value: t}), nil
+		}
+		return clause.And(sd.Trashed(), clause.Lt{Column: column, Value: unixTime(t, sd.DeletedAtUnit)}), nil
+	default:
+		return clause.And(sd.Trashed(), clause.Lt{Column: sd.column(), Value: unixTime(t, sd.DeletedAtUnit)}), nil
+	}
+}
+
+// RestoreValues 返回恢复记录时需要写入的列：软删除字段与 DeletedAtField 还原为未删除时的取值
+func (sd SoftDelete) RestoreValues() map[string]interface{} {
+	values := map[string]interface{}{sd.Field.DBName: sd.ActiveValue()}
+	if f := sd.DeletedAtField; f != nil {
+		if f.GORMDataType == schema.Time {
+			values[f.DBName] = nil
+		} else {
+			values[f.DBName] = 0
+		}
+	}
+	return values
+}
+
+// UniqueKeys 返回模型的全部唯一约束（gormx:"unique"、gorm:"uniqueIndex"、gorm:"unique"）的业务列，
+// 不含软删除列与主键；带 WHERE 的部分唯一索引无法在应用层判断，跳过
+func UniqueKeys(s *schema.Schema, namer schema.Namer) []UniqueIndex {
+	if s == nil {
+		return nil
+	}
+
+	var (
+		keys    []UniqueIndex
+		seen    = make(map[string]bool)
+		deleted = ""
+	)
+	if field := SoftDeleteField(s); field != nil {
+		deleted = field.DBName
+	}
+	add := func(name string, columns []string) {
+		columns = slices.DeleteFunc(slices.Clone(columns), func(column string) bool { return column == deleted })
+		if len(columns) == 0 || seen[strings.Join(columns, ",")] {
+			return
+		}
+		seen[strings.Join(columns, ",")] = true
+		keys = append(keys, UniqueIndex{Name: name, Columns: columns})
+	}
+
+	for _, index := range UniqueIndexes(s, namer) {
+		add(index.Name, index.Columns)
+	}
+	for _, index := range s.ParseIndexes() {
+		if index.Class != "UNIQUE" || index.Where != "" {
+			continue
+		}
+		columns := make([]string, 0, len(index.Fields))
+		for _, option := range index.Fields {
+			if option.Field == nil || option.Expression != "" {
+				columns = nil
+				break
+			}
+			columns = append(columns, option.DBName)
+		}
+		if columns != nil {
+			add(index.Name, columns)
+		}
+	}
+	for _, field := range s.Fields {
+		if field.Unique && !field.PrimaryKey && field.DBName != "" {
+			add(namer.UniqueName(s.Table, field.DBName), []string{field.DBName})
+		}
+	}
+	return keys
+}
+
+// unixTime 按单位将时间转换为整数
+func unixTime(t time.Time, unit schema.TimeType) int64 {
+	switch unit {
+	case schema.UnixNanosecond:
+		return t.UnixNano()
+	case schema.UnixMillisecond:
+		return t.UnixMilli()
+	default:
+		return t.Unix()
+	}
+}
@@ -0,0 +1,367 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	// OperationSelect 表示查询语句
+	OperationSelect = "SELECT"
+	// OperationInsert 表示插入语句
+	OperationInsert = "INSERT"
+	// OperationUpdate 表示更新语句
+	OperationUpdate = "UPDATE"
+	// OperationDelete 表示删除语句
+	OperationDelete = "DELETE"
+	// OperationDDL 表示建表、改表等结构变更语句
+	OperationDDL = "DDL"
+	// OperationOther 表示其它语句（SET/SHOW/BEGIN 等）
+	OperationOther = "OTHER"
+)
+
+// statementKey 为 gorm.Statement 在 context 中的 key
+type statementKey struct{}
+
+// WithStatement 将当前执行的 gorm.Statement 写入 context，供 logger 读取表名等信息
+func WithStatement(ctx context.Context, stmt *gorm.Statement) context.Context {
+	return context.WithValue(ctx, statementKey{}, stmt)
+}
+
+// StatementFromContext 读取 WithStatement 写入的 gorm.Statement
+func StatementFromContext(ctx context.Context) *gorm.Statement {
+	if ctx == nil {
+		return nil
+	}
+	stmt, _ := ctx.Value(statementKey{}).(*gorm.Statement)
+	return stmt
+}
+
+// StatementPlugin 为每条语句把 gorm.Statement 注入 Statement.Context
+type StatementPlugin struct{}
+
+// Name 实现 gorm.Plugin
+func (StatementPlugin) Name() string {
+	return "gormx:statement"
+}
+
+// Initialize 实现 gorm.Plugin，在所有回调之前注入 Statement
+func (p StatementPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register(p.Name(), injectStatement); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register(p.Name(), injectStatement); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register(p.Name(), injectStatement); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register(p.Name(), injectStatement); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register(p.Name(), injectStatement); err != nil {
+		return err
+	}
+	return cb.Raw().Before("*").Register(p.Name(), injectStatement)
+}
+
+// injectStatement 将 Statement 写入 Statement.Context（同一 Statement 复用时不重复包装）
+func injectStatement(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Context == nil || StatementFromContext(stmt.Context) == stmt {
+		return
+	}
+	stmt.Context = WithStatement(stmt.Context, stmt)
+}
+
+// StatementOperation 根据 SQL 首个关键字判断语句类型
+func StatementOperation(sql string) string {
+	keyword := strings.ToUpper(firstKeyword(sql))
+	switch keyword {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN":
+		return OperationSelect
+	case "INSERT", "REPLACE":
+		return OperationInsert
+	case "UPDATE":
+		return OperationUpdate
+	case "DELETE":
+		return OperationDelete
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT":
+		return OperationDDL
+	default:
+		return OperationOther
+	}
+}
+
+// firstKeyword 跳过前导空白、注释与括号后取出第一个单词
+func firstKeyword(sql string) string {
+	i := 0
+	for i < len(sql) {
+		switch {
+		case sql[i] == ' ' || sql[i] == '\t' || sql[i] == '\n' || sql[i] == '\r' || sql[i] == '(':
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				return ""
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				return ""
+			}
+		default:
+			j := i
+			for j < len(sql) && isWordChar(sql[j]) {
+				j++
+			}
+			return sql[i:j]
+		}
+	}
+	return ""
+}
+
+// NormalizeSQL 将 SQL 归一化为查询形状：去除注释与字面量，压缩空白与 IN/VALUES 列表
+func NormalizeSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	// space 表示上一个输出是否为空白，用于压缩连续空白
+	space := false
+	writeByte := func(c byte) {
+		b.WriteByte(c)
+		space = false
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+				space = true
+			}
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case c == '\'':
+			// 字符串字面量，支持 '' 与 \' 转义
+			i++
+			for i < len(sql) {
+				if sql[i] == '\\' {
+					i += 2
+					continue
+				}
+				if sql[i] == '\'' {
+					if i+1 < len(sql) && sql[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+			writeByte('?')
+		case c == '"' || c == '`':
+			// 带引号的标识符原样保留
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				end = len(sql) - i - 1
+			}
+			b.WriteString(sql[i : i+end+2])
+			space = false
+			i += end + 2
+		case c == '$' && i+1 < len(sql) && isDigit(sql[i+1]):
+			// Postgres 占位符 $1
+			i++
+			for i < len(sql) && isDigit(sql[i]) {
+				i++
+			}
+			writeByte('?')
+		case isDigit(c) || (c == '-' && i+1 < len(sql) && isDigit(sql[i+1]) && !prevIsOperand(b.String())):
+			// 数字字面量（含负数与小数）
+			i++
+			for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.' || sql[i] == 'e' || sql[i] == 'E') {
+				i++
+			}
+			writeByte('?')
+		case isWordChar(c):
+			j := i
+			for j < len(sql) && isWordChar(sql[j]) {
+				j++
+			}
+			word := strings.ToLower(sql[i:j])
+			if word == "true" || word == "false" {
+				word = "?"
+			}
+			b.WriteString(word)
+			space = false
+			i = j
+		default:
+			writeByte(c)
+			i++
+		}
+	}
+
+	return collapseLists(strings.TrimSpace(b.String()))
+}
+
+// collapseLists 将 (?, ?, ?) 压缩为 (?+)，并把连续的 (?+), (?+) 压缩为一个
+func collapseLists(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		if sql[i] != '(' {
+			b.WriteByte(sql[i])
+			i++
+			continue
+		}
+		// 尝试匹配只含 ? 的列表
+		j, ok := matchPlaceholderList(sql, i)
+		if !ok {
+			b.WriteByte(sql[i])
+			i++
+			continue
+		}
+		b.WriteString("(?+)")
+		i = j
+		// 吞掉后续重复的 (?+) 列表（多行 VALUES）
+		for {
+			k := i
+			for k < len(sql) && (sql[k] == ' ' || sql[k] == ',') {
+				k++
+			}
+			if k == i || k >= len(sql) || sql[k] != '(' {
+				break
+			}
+			next, ok := matchPlaceholderList(sql, k)
+			if !ok {
+				break
+			}
+			i = next
+		}
+	}
+
+	return b.String()
+}
+
+// matchPlaceholderList 判断 sql[start:] 是否以 "(?, ?, ...)" 开头，返回结束位置
+func matchPlaceholderList(sql string, start int) (int, bool) {
+	seen := false
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '?':
+			seen = true
+		case ' ', ',':
+		case ')':
+			return i + 1, seen
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// fingerprintCacheSize 为指纹缓存的上限，超过后不再写入缓存
+const fingerprintCacheSize = 4096
+
+// fingerprintCache 以 SQL 文本为 key 缓存指纹，同一形状的语句只归一化一次
+var fingerprintCache = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// SQLFingerprint 归一化 sql 并返回其指纹，结果按 SQL 文本缓存（建议传入带占位符的 SQL 以提高命中率）
+func SQLFingerprint(sql string) string {
+	fingerprintCache.RLock()
+	fp, ok := fingerprintCache.m[sql]
+	fingerprintCache.RUnlock()
+	if ok {
+		return fp
+	}
+
+	fp = Fingerprint(NormalizeSQL(sql))
+
+	fingerprintCache.Lock()
+	if len(fingerprintCache.m) < fingerprintCacheSize {
+		fingerprintCache.m[strings.Clone(sql)] = fp
+	}
+	fingerprintCache.Unlock()
+
+	return fp
+}
+
+// Fingerprint 返回归一化 SQL 的 FNV-64a 十六进制摘要，相同形状的 SQL 得到相同指纹
+func Fingerprint(normalized string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(normalized))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// ErrorCode 提取驱动原生错误码与 SQLSTATE（MySQL 为错误号，Postgres 错误码即 SQLSTATE）
+func ErrorCode(err error) (code, sqlState string) {
+	if err == nil {
+		return "", ""
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		code = strconv.FormatUint(uint64(mysqlErr.Number), 10)
+		if mysqlErr.SQLState != [5]byte{} {
+			sqlState = string(mysqlErr.SQLState[:])
+		}
+		return code, sqlState
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code, pgErr.Code
+	}
+
+	return "", ""
+}
+
+// isWordChar 判断是否为标识符字符
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c) || c >= 0x80
+}
+
+// isDigit 判断是否为数字
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// prevIsOperand 判断已输出内容的末尾是否为操作数（此时 '-' 为减号而非负号）
+func prevIsOperand(s string) bool {
+	s = strings.TrimRight(s, " ")
+	if s == "" {
+		return false
+	}
+	c := s[len(s)-1]
+	return c == ')' || c == '?' || isWordChar(c)
+}
@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// TagUnique 为 gormx 标签中声明复合唯一索引的键，格式为 gormx:"unique" 或 gormx:"unique:<group>"
+const TagUnique = "UNIQUE"
+
+// UniqueIndex 为由 gormx 标签声明的复合唯一索引
+type UniqueIndex struct {
+	Name    string
+	Columns []string
+}
+
+// UniqueIndexes 按分组收集 gormx:"unique[:group]" 标签声明的字段，构造复合唯一索引；
+// 模型带租户字段时自动加入租户列，带软删除字段时自动追加为最后一列，软删除后的记录不再占用业务唯一键
+func UniqueIndexes(s *schema.Schema, namer schema.Namer) []UniqueIndex {
+	if s == nil {
+		return nil
+	}
+
+	var (
+		indexes []UniqueIndex
+		groups  = make(map[string]int)
+	)
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		group, ok := schema.ParseTagSetting(field.Tag.Get("gormx"), ";")[TagUnique]
+		if !ok {
+			continue
+		}
+		// 索引名按表名生成，避免 Postgres 同一 schema 下不同表的索引重名
+		if group == TagUnique || group == "" {
+			group = "unique"
+		} else {
+			group = "unique_" + group
+		}
+		i, ok := groups[group]
+		if !ok {
+			i = len(indexes)
+			groups[group] = i
+			indexes = append(indexes, UniqueIndex{Name: namer.IndexName(s.Table, group)})
+		}
+		indexes[i].Columns = append(indexes[i].Columns, field.DBName)
+	}
+
+	// 多租户模型的业务唯一键按租户隔离，租户列作为第一列
+	if tenant := TenantField(s); tenant != nil {
+		for i := range indexes {
+			if !slices.Contains(indexes[i].Columns, tenant.DBName) {
+				indexes[i].Columns = append([]string{tenant.DBName}, indexes[i].Columns...)
+			}
+		}
+	}
+
+	if deletedAt := SoftDeleteField(s); deletedAt != nil {
+		for i := range indexes {
+			indexes[i].Columns = append(indexes[i].Columns, deletedAt.DBName)
+		}
+	}
+
+	return indexes
+}
+
+// TagID 为 gormx 标签中声明 ID 生成器的键，格式为 gormx:"id:<generator>"
+const TagID = "ID"
+
+// IDField 为由 gormx:"id:<generator>" 标签声明、创建时自动生成 ID 的字段
+type IDField struct {
+	Field     *schema.Field
+	Generator string
+}
+
+// IDFields 收集模型中 gormx:"id:<generator>" 标签声明的字段
+func IDFields(s *schema.Schema) []IDField {
+	if s == nil {
+		return nil
+	}
+
+	var fields []IDField
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if generator, ok := schema.ParseTagSetting(field.Tag.Get("gormx"), ";")[TagID]; ok && generator != TagID {
+			fields = append(fields, IDField{Field: field, Generator: generator})
+		}
+	}
+	return fields
+}
+
+const (
+	// TagCreatedBy 声明创建人字段，格式为 gormx:"created_by"
+	TagCreatedBy = "CREATED_BY"
+	// TagUpdatedBy 声明最后修改人字段，格式为 gormx:"updated_by"
+	TagUpdatedBy = "UPDATED_BY"
+	// TagDeletedBy 声明删除人字段（配合软删除），格式为 gormx:"deleted_by"
+	TagDeletedBy = "DELETED_BY"
+)
+
+// AuditFields 为模型中由 gormx 标签声明的审计字段，未声明的为 nil
+type AuditFields struct {
+	CreatedBy *schema.Field
+	UpdatedBy *schema.Field
+	DeletedBy *schema.Field
+}
+
+// Empty 判断模型是否没有任何审计字段
+func (a AuditFields) Empty() bool {
+	return a.CreatedBy == nil && a.UpdatedBy == nil && a.DeletedBy == nil
+}
+
+// ParseAuditFields 收集模型中 gormx:"created_by" / "updated_by" / "deleted_by" 标签声明的字段
+func ParseAuditFields(s *schema.Schema) AuditFields {
+	var fields AuditFields
+	if s == nil {
+		return fields
+	}
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		settings := schema.ParseTagSetting(field.Tag.Get("gormx"), ";")
+		if _, ok := settings[TagCreatedBy]; ok {
+			fields.CreatedBy = field
+		}
+		if _, ok := settings[TagUpdatedBy]; ok {
+			fields.UpdatedBy = field
+		}
+		if _, ok := settings[TagDeletedBy]; ok {
+			fields.DeletedBy = field
+		}
+	}
+	return fields
+}
+
+// TagTenant 声明租户字段，格式为 gormx:"tenant"
+const TagTenant = "TENANT"
+
+// TenantField 返回模型中 gormx:"tenant" 标签声明的租户字段，没有时返回 nil
+func TenantField(s *schema.Schema) *schema.Field {
+	if s == nil {
+		return nil
+	}
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		if _, ok := schema.ParseTagSetting(field.Tag.Get("gormx"), ";")[TagTenant]; ok {
+			return field
+		}
+	}
+	return nil
+}
+
+// TagRetention 声明软删除记录的保留期，格式为 gormx:"retention:30d"（支持 d 与 time.ParseDuration 的单位），
+// 通常写在空白字段上：_ struct{} `gormx:"retention:30d"`
+const TagRetention = "RETENTION"
+
+// Retention 返回模型通过 gormx:"retention" 标签声明的保留期，没有声明时 ok 为 false
+func Retention(s *schema.Schema) (retention time.Duration, ok bool, err error) {
+	if s == nil {
+		return 0, false, nil
+	}
+	value, ok := retentionTag(s.ModelType)
+	if !ok {
+		return 0, false, nil
+	}
+	retention, err = ParseRetention(value)
+	return retention, err == nil, err
+}
+
+// ParseRetention 解析保留期，在 time.ParseDuration 的基础上支持天（如 30d）
+func ParseRetention(value string) (time.Duration, error) {
+	var (
+		d   time.Duration
+		err error
+	)
+	if days, found := strings.CutSuffix(value, "d"); found {
+		var n int
+		if n, err = strconv.Atoi(days); err == nil {
+			d = time.Duration(n) * 24 * time.Hour
+		}
+	} else {
+		d, err = time.ParseDuration(value)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid retention %q", value)
+	}
+	return d, nil
+}
+
+// retentionTag 查找结构体（含匿名嵌入的结构体与空白字段）上的 retention 标签
+func retentionTag(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if value, ok := schema.ParseTagSetting(field.Tag.Get("gormx"), ";")[TagRetention]; ok && value != TagRetention {
+			return value, true
+		}
+		if field.Anonymous {
+			if value, ok := retentionTag(field.Type); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// TagBlindIndex 声明加密字段的盲索引列，格式为 gormx:"blind_index:<字段名>"
+const TagBlindIndex = "BLIND_INDEX"
+
+// BlindIndexField 为由 gormx:"blind_index:<字段名>" 标签声明的盲索引列及其来源字段
+type BlindIndexField struct {
+	Field  *schema.Field
+	Source *schema.Field
+}
+
+// BlindIndexFields 收集模型中 gormx:"blind_index:<字段名>" 标签声明的盲索引列，来源字段不存在时返回错误
+func BlindIndexFields(s *schema.Schema) ([]BlindIndexField, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var fields []BlindIndexField
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		name, ok := schema.ParseTagSetting(field.Tag.Get("gormx"), ";")[TagBlindIndex]
+		if !ok {
+			continue
+		}
+		source := s.LookUpField(name)
+		if source == nil || source.DBName == "" || name == TagBlindIndex {
+			return nil, fmt.Errorf("%s.%s: blind index source field %q not found", s.Name, field.Name, name)
+		}
+		fields = append(fields, BlindIndexField{Field: field, Source: source})
+	}
+	return fields, nil
+}
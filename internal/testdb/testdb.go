@@ -0,0 +1,192 @@
+// Package testdb 为 gormx 的单元测试提供不依赖真实数据库的 database/sql 驱动：
+// 记录执行的 SQL，查询结果与影响行数由测试指定
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Rows 为查询返回的结果集
+type Rows struct {
+	Columns []string
+	Values  [][]driver.Value
+}
+
+// DB 记录执行的语句，Query 与 Exec 为 nil 时分别返回空结果集与 1 行影响
+type DB struct {
+	// Query 返回查询的结果集
+	Query func(query string, args []driver.Value) (*Rows, error)
+	// Exec 返回语句影响的行数
+	Exec func(query string, args []driver.Value) (int64, error)
+
+	mu         sync.Mutex
+	statements []string
+}
+
+// Open 以 dialect（postgres 或 mysql）方言打开 gorm 连接
+func (d *DB) Open(dialect string) (*gorm.DB, error) {
+	sqlDB := sql.OpenDB(connector{db: d})
+	var dialector gorm.Dialector
+	switch dialect {
+	case "postgres":
+		dialector = postgres.New(postgres.Config{Conn: sqlDB})
+	case "mysql":
+		dialector = mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true})
+	default:
+		return nil, fmt.Errorf("testdb: unsupported dialect %q", dialect)
+	}
+	return gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+}
+
+// Statements 返回已执行的语句，格式为 "<SQL> <参数>"，事务记录为 BEGIN、COMMIT 与 ROLLBACK
+func (d *DB) Statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.statements...)
+}
+
+// Reset 清空已记录的语句
+func (d *DB) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = nil
+}
+
+// record 记录一条语句
+func (d *DB) record(statement string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, statement)
+}
+
+// format 将语句与参数格式化为记录的形式
+func format(query string, args []driver.Value) string {
+	if len(args) == 0 {
+		return query
+	}
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = fmt.Sprint(arg)
+	}
+	return query + " [" + strings.Join(values, ", ") + "]"
+}
+
+// connector 实现 driver.Connector
+type connector struct {
+	db *DB
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return conn(c), nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return c
+}
+
+func (c connector) Open(string) (driver.Conn, error) {
+	return conn(c), nil
+}
+
+// conn 实现 driver.Conn 与 driver.Tx
+type conn struct {
+	db *DB
+}
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	return stmt{db: c.db, query: query}, nil
+}
+
+func (c conn) Close() error {
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return c, nil
+}
+
+func (c conn) Commit() error {
+	c.db.record("COMMIT")
+	return nil
+}
+
+func (c conn) Rollback() error {
+	c.db.record("ROLLBACK")
+	return nil
+}
+
+// stmt 实现 driver.Stmt
+type stmt struct {
+	db    *DB
+	query string
+}
+
+func (s stmt) Close() error {
+	return nil
+}
+
+func (s stmt) NumInput() int {
+	return -1
+}
+
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.record(format(s.query, args))
+	if s.db.Exec == nil {
+		return driver.RowsAffected(1), nil
+	}
+	n, err := s.db.Exec(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.record(format(s.query, args))
+	if s.db.Query == nil {
+		return &rows{}, nil
+	}
+	result, err := s.db.Query(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &rows{}, nil
+	}
+	return &rows{Rows: *result}, nil
+}
+
+// rows 实现 driver.Rows
+type rows struct {
+	Rows
+	next int
+}
+
+func (r *rows) Columns() []string {
+	return r.Rows.Columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.Values) {
+		return io.EOF
+	}
+	copy(dest, r.Values[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddWhere 以 AND 追加 WHERE 条件；已有条件中含 Or 时先整体加括号，避免 a OR b AND c 的优先级问题
+func AddWhere(stmt *gorm.Statement, exprs ...clause.Expression) {
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 1 {
+			for _, expr := range where.Exprs {
+				if _, ok := expr.(clause.OrConditions); ok {
+					c.Expression = clause.Where{Exprs: []clause.Expression{clause.And(where.Exprs...)}}
+					stmt.Clauses["WHERE"] = c
+					break
+				}
+			}
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: exprs})
+}
+
+// HasConditions 判断更新/删除语句是否已有 WHERE 条件，或模型（含切片元素）带有非零主键；
+// 两者都没有时 gorm 会返回 ErrMissingWhereClause，追加的附加条件不应使其失效
+func HasConditions(stmt *gorm.Statement) bool {
+	if _, ok := stmt.Clauses["WHERE"]; ok {
+		return true
+	}
+	if stmt.Schema == nil {
+		return false
+	}
+
+	hasPrimaryKey := func(row reflect.Value) bool {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct {
+			return false
+		}
+		for _, field := range stmt.Schema.PrimaryFields {
+			if _, zero := field.ValueOf(stmt.Context, row); !zero {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if hasPrimaryKey(stmt.ReflectValue.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		return hasPrimaryKey(stmt.ReflectValue)
+	}
+	return false
+}
+
+// PrimaryKeys 返回匹配 rows 主键的条件，单列主键使用 IN，复合主键以 OR 连接
+func PrimaryKeys(names []string, rows []map[string]interface{}) clause.Expression {
+	if len(names) == 1 {
+		values := make([]interface{}, len(rows))
+		for i, row := range rows {
+			values[i] = row[names[0]]
+		}
+		return clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: names[0]}, Values: values}
+	}
+
+	ors := make([]clause.Expression, len(rows))
+	for i, row := range rows {
+		eqs := make([]clause.Expression, len(names))
+		for j, name := range names {
+			eqs[j] = clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: name}, Value: row[name]}
+		}
+		ors[i] = clause.And(eqs...)
+	}
+	return clause.Or(ors...)
+}
@@ -0,0 +1,80 @@
+package gormx
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSON 为以 JSON 存储的任意类型：MySQL 使用 json，Postgres 使用 jsonb，其余数据库使用 text。
+// 序列化为 JSON（如接口响应）时与 Data 本身一致，不会多出一层 {"Data": ...}。
+type JSON[T any] struct {
+	Data T
+}
+
+// NewJSON 包装 v
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{Data: v}
+}
+
+// Scan 实现 sql.Scanner，NULL 扫描为零值
+func (j *JSON[T]) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*j = JSON[T]{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("gormx: cannot scan %T into JSON", value)
+	}
+
+	var decoded T
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	j.Data = decoded
+	return nil
+}
+
+// Value 实现 driver.Valuer，写入 JSON 字符串
+func (j JSON[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface
+func (JSON[T]) GormDataType() string {
+	return "json"
+}
+
+// GormDBDataType 按方言返回列类型
+func (JSON[T]) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "jsonb"
+	case "mysql", "sqlite":
+		return "json"
+	default:
+		return "text"
+	}
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Data)
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (j *JSON[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.Data)
+}
@@ -0,0 +1,209 @@
+package gormx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	loger "gorm.io/gorm/logger"
+)
+
+const (
+	// defaultLeakTxThreshold 为默认的事务未结束告警阈值。
+	defaultLeakTxThreshold = 30 * time.Second
+	// defaultLeakCheckInterval 为默认的巡检间隔。
+	defaultLeakCheckInterval = 10 * time.Second
+	// maxReportedTransactions 为连接池等待告警中最多列出的未结束事务数量。
+	maxReportedTransactions = 5
+)
+
+// LeakDetectorConf 为连接与事务泄漏检测（调试模式）的配置。
+type LeakDetectorConf struct {
+	// 事务未结束告警阈值（秒，默认30）
+	// TxThreshold 为事务从 Begin 起超过该时长仍未 Commit/Rollback 时输出 warn 日志。
+	TxThreshold int `json:"tx_threshold"`
+	// 巡检间隔（秒，默认10）
+	// CheckInterval 为检查未结束事务与连接池等待指标的间隔。
+	CheckInterval int `json:"check_interval"`
+}
+
+// leakDetector 记录每个事务的开启栈与开始时间，定期巡检长事务与连接池等待。
+type leakDetector struct {
+	logger    loger.Interface
+	sqlDB     *sql.DB
+	threshold time.Duration
+	interval  time.Duration
+
+	mu   sync.Mutex
+	seq  uint64
+	open map[uint64]*txRecord
+	// lastStats 为上次巡检时的连接池统计，用于计算区间内的等待增量。
+	lastStats sql.DBStats
+}
+
+// txRecord 为一个未结束事务的记录。
+type txRecord struct {
+	ctx      context.Context
+	start    time.Time
+	stack    []uintptr
+	reported bool
+}
+
+// newLeakDetector 根据配置构造泄漏检测器。
+func newLeakDetector(c LeakDetectorConf, logger loger.Interface, sqlDB *sql.DB) *leakDetector {
+	d := &leakDetector{
+		logger:    logger,
+		sqlDB:     sqlDB,
+		threshold: time.Second * time.Duration(c.TxThreshold),
+		interval:  time.Second * time.Duration(c.CheckInterval),
+		open:      make(map[uint64]*txRecord),
+	}
+	if d.threshold <= 0 {
+		d.threshold = defaultLeakTxThreshold
+	}
+	if d.interval <= 0 {
+		d.interval = defaultLeakCheckInterval
+	}
+	return d
+}
+
+// useLeakDetector 为 db 挂载泄漏检测：包装事务并启动巡检协程（*sql.DB 关闭后协程自动退出）。
+func useLeakDetector(db *gorm.DB, c LeakDetectorConf) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	d := newLeakDetector(c, db.Logger, sqlDB)
+	pool := wrapConnPool(db)
+	pool.observers = append(pool.observers, d.observe)
+	go d.run()
+
+	return nil
+}
+
+// observe 实现 txObserver，记录事务的开启栈与开始时间。
+func (d *leakDetector) observe(tx *connTx) func(committed bool) {
+	rec := &txRecord{ctx: tx.ctx, start: time.Now(), stack: internal.CallerStack()}
+
+	d.mu.Lock()
+	d.seq++
+	id := d.seq
+	d.open[id] = rec
+	d.mu.Unlock()
+
+	// 事务对象被回收时仍未结束，说明既没有 Commit 也没有 Rollback，其占用的连接要到 context 取消时才会归还。
+	runtime.AddCleanup(tx, d.collected, id)
+
+	return func(bool) {
+		d.mu.Lock()
+		delete(d.open, id)
+		d.mu.Unlock()
+	}
+}
+
+// collected 在事务对象被 GC 回收时调用。
+func (d *leakDetector) collected(id uint64) {
+	d.mu.Lock()
+	rec, ok := d.open[id]
+	delete(d.open, id)
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	d.logger.Warn(context.WithoutCancel(rec.ctx), "transaction leaked: garbage collected after %s without Commit or Rollback, its connection is held until the context is cancelled (forever for a non-cancellable context)\nbegin at:\n%s",
+		time.Since(rec.start).Round(time.Millisecond), internal.FormatStack(rec.stack))
+}
+
+// run 按间隔巡检，直到 *sql.DB 被关闭。
+func (d *leakDetector) run() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	d.lastStats = d.sqlDB.Stats()
+	for range ticker.C {
+		if isDBClosed(d.sqlDB) {
+			return
+		}
+		d.checkTransactions()
+		d.checkPool()
+	}
+}
+
+// checkTransactions 对超过阈值仍未结束的事务输出一次 warn 日志。
+func (d *leakDetector) checkTransactions() {
+	now := time.Now()
+
+	d.mu.Lock()
+	var long []*txRecord
+	for _, rec := range d.open {
+		if !rec.reported && now.Sub(rec.start) >= d.threshold {
+			rec.reported = true
+			long = append(long, rec)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, rec := range long {
+		d.logger.Warn(context.WithoutCancel(rec.ctx), "transaction open for %s without Commit or Rollback (threshold %s)\nbegin at:\n%s",
+			now.Sub(rec.start).Round(time.Millisecond), d.threshold, internal.FormatStack(rec.stack))
+	}
+}
+
+// checkPool 根据 sql.DBStats 的等待指标判断连接是否被长时间占用，并列出最久未结束的事务。
+func (d *leakDetector) checkPool() {
+	stats := d.sqlDB.Stats()
+	waits := stats.WaitCount - d.lastStats.WaitCount
+	waited := stats.WaitDuration - d.lastStats.WaitDuration
+	d.lastStats = stats
+	if waits <= 0 {
+		return
+	}
+
+	var b strings.Builder
+	for _, rec := range d.oldestTransactions() {
+		b.WriteString("\ntransaction open for ")
+		b.WriteString(time.Since(rec.start).Round(time.Millisecond).String())
+		b.WriteString(", begin at:\n")
+		b.WriteString(internal.FormatStack(rec.stack))
+	}
+
+	d.logger.Warn(context.Background(), "connection pool exhausted: %d waits (%s) in last %s, in_use=%d idle=%d open=%d max_open=%d%s",
+		waits, waited.Round(time.Millisecond), d.interval, stats.InUse, stats.Idle, stats.OpenConnections, stats.MaxOpenConnections, b.String())
+}
+
+// oldestTransactions 返回开始时间最早的若干个未结束事务。
+func (d *leakDetector) oldestTransactions() []*txRecord {
+	d.mu.Lock()
+	recs := make([]*txRecord, 0, len(d.open))
+	for _, rec := range d.open {
+		recs = append(recs, rec)
+	}
+	d.mu.Unlock()
+
+	slices.SortFunc(recs, func(a, b *txRecord) int {
+		return a.start.Compare(b.start)
+	})
+	return recs[:min(len(recs), maxReportedTransactions)]
+}
+
+// isDBClosed 判断 *sql.DB 是否已关闭：使用已取消的 context 获取连接，
+// 未关闭时立即返回 context.Canceled 而不会占用连接。
+func isDBClosed(sqlDB *sql.DB) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	conn, err := sqlDB.Conn(ctx)
+	if err == nil {
+		_ = conn.Close()
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
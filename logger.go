@@ -32,5 +32,15 @@ func NewLogger(c *Conf) loger.Interface {
 		Database: c.Database,
 		// DatabaseType 记录库类型，便于日志聚合。
 		DatabaseType: c.Type,
+		// Sinks 为结构化日志输出目标。
+		Sinks: c.sinks,
+		// DisableCaller 控制是否定位调用方。
+		DisableCaller: c.disableCaller,
+		// Extractor 从请求上下文中提取调用方信息。
+		Extractor: c.metadataExtractor(),
+		// ExplainSlowQuery 控制慢 SQL 是否附带执行计划。
+		ExplainSlowQuery: c.ExplainSlowQuery,
+		// ExplainInterval 为同一指纹的 EXPLAIN 间隔（秒）。
+		ExplainInterval: time.Second * time.Duration(c.ExplainInterval),
 	})
 }
@@ -0,0 +1,49 @@
+package gormx
+
+import (
+	"context"
+
+	"github.com/fireflycore/gormx/internal"
+)
+
+// Metadata 为从请求上下文中提取的调用方信息（用户、应用、租户等）。
+type Metadata = internal.Metadata
+
+// MetadataExtractor 从请求上下文中提取 Metadata，可通过 Conf.WithMetadataExtractor 设置。
+type MetadataExtractor = internal.MetadataExtractor
+
+// MetadataExtractorFunc 为函数形式的 MetadataExtractor。
+type MetadataExtractorFunc = internal.MetadataExtractorFunc
+
+// ContextKeys 为业务自定义的 context key，对应的值需为 string。
+type ContextKeys = internal.ContextKeys
+
+// NewGRPCMetadataExtractor 读取 gRPC incoming metadata（未设置 MetadataExtractor 时的默认行为）。
+func NewGRPCMetadataExtractor() MetadataExtractor {
+	return internal.NewGRPCExtractor()
+}
+
+// NewBaggageMetadataExtractor 读取 OTel baggage，成员名与 go-micro 的 metadata key 一致。
+func NewBaggageMetadataExtractor() MetadataExtractor {
+	return internal.NewBaggageExtractor()
+}
+
+// NewContextKeyMetadataExtractor 按业务自定义的 context key 读取。
+func NewContextKeyMetadataExtractor(keys ContextKeys) MetadataExtractor {
+	return internal.NewContextKeyExtractor(keys)
+}
+
+// NewContextMetadataExtractor 读取 WithMetadata 写入 context 的值。
+func NewContextMetadataExtractor() MetadataExtractor {
+	return internal.NewContextExtractor()
+}
+
+// ChainMetadataExtractors 组合多个 MetadataExtractor，每个字段取第一个非空值。
+func ChainMetadataExtractors(extractors ...MetadataExtractor) MetadataExtractor {
+	return internal.NewChainExtractor(extractors...)
+}
+
+// WithMetadata 将 Metadata 写入 context，适用于 HTTP handler 与后台任务。
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return internal.WithMetadata(ctx, md)
+}
@@ -0,0 +1,34 @@
+package gormx
+
+import (
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MigrateUniqueIndexes 为模型创建 gormx:"unique[:group]" 标签声明的复合唯一索引（已存在则跳过）。
+// 同一分组的字段按声明顺序组成一个索引，模型带软删除字段时自动追加 deleted_at，
+// 索引名按表名生成（idx_<table>_unique / idx_<table>_unique_<group>），NewMysql/NewPostgres 开启 AutoMigrate 时会自动调用。
+func MigrateUniqueIndexes(db *gorm.DB, models ...interface{}) error {
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+
+		for _, idx := range internal.UniqueIndexes(stmt.Schema, db.NamingStrategy) {
+			if db.Migrator().HasIndex(model, idx.Name) {
+				continue
+			}
+
+			columns := make([]interface{}, 0, len(idx.Columns))
+			for _, column := range idx.Columns {
+				columns = append(columns, clause.Column{Name: column})
+			}
+			if err := db.Exec("CREATE UNIQUE INDEX ? ON ??", clause.Column{Name: idx.Name}, clause.Table{Name: stmt.Table}, columns).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
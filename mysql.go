@@ -10,7 +10,6 @@ import (
 	"github.com/fireflycore/go-utils/network"
 	"github.com/fireflycore/go-utils/tlsx"
 	"github.com/go-sql-driver/mysql"
-	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
 	mysql2 "gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -102,12 +101,8 @@ func NewMysql(mc *MysqlConf, tables []interface{}) (*MysqlDB, error) {
 		return nil, err
 	}
 
-	// 启用 otelgorm 插件（Tracing），如果配置了 Logger 开启。
-	// 这里默认始终开启 Tracing，只要初始化了 DB；是否上报取决于外部是否有 TracerProvider。
-	// 但为了稳妥，通常只有明确需要 observability 时才开启。
-	// 鉴于用户要求“全量替换”，且 go-micro 侧有开关控制，这里我们总是尝试挂载插件。
-	// 插件内部会检查全局 TracerProvider，如果没有注册则只会产生空操作，开销极小。
-	if err = db.Use(otelgorm.NewPlugin(otelgorm.WithDBName(mc.Database))); err != nil {
+	// 挂载 gormx 内置插件（Tracing、Statement 注入以及按配置启用的检测插件）。
+	if err = usePlugins(db, &mc.Conf); err != nil {
 		return nil, err
 	}
 
@@ -117,6 +112,10 @@ func NewMysql(mc *MysqlConf, tables []interface{}) (*MysqlDB, error) {
 		if err = db.AutoMigrate(tables...); err != nil {
 			return nil, err
 		}
+		// 创建 gormx:"unique" 标签声明的复合唯一索引（含 deleted_at）。
+		if err = MigrateUniqueIndexes(db, tables...); err != nil {
+			return nil, err
+		}
 	}
 
 	// 获取底层 *sql.DB 以配置连接池参数。
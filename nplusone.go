@@ -0,0 +1,148 @@
+package gormx
+
+import (
+	"context"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultNPlusOneThreshold 为默认的重复查询阈值。
+	defaultNPlusOneThreshold = 10
+	// maxTrackedCallers 为每个查询形状最多记录的调用点数量。
+	maxTrackedCallers = 5
+)
+
+// NPlusOneConf 为 N+1 查询检测的配置。
+type NPlusOneConf struct {
+	// 同一请求内相同查询形状的重复次数阈值（默认10），达到后输出 warn 日志
+	// Threshold 按 SQL 指纹统计。
+	Threshold int `json:"threshold"`
+	// 请求采样率（0-1，默认1即全部检测，生产环境建议调低）
+	// SampleRate 在请求首次执行查询时决定该请求是否参与检测。
+	SampleRate float64 `json:"sample_rate"`
+}
+
+// queryTrackerKey 为 queryTracker 在 context 中的 key。
+type queryTrackerKey struct{}
+
+// WithQueryTracker 为请求上下文挂载查询统计，N+1 检测按该上下文统计语句（通常在 gRPC/HTTP 中间件中调用）。
+func WithQueryTracker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryTrackerKey{}, &queryTracker{shapes: make(map[string]*queryShape)})
+}
+
+// queryTracker 为单个请求内的查询统计。
+type queryTracker struct {
+	// sampleOnce 保证采样只决定一次。
+	sampleOnce sync.Once
+	sampled    bool
+
+	mu     sync.Mutex
+	shapes map[string]*queryShape
+}
+
+// queryShape 为同一指纹查询的统计。
+type queryShape struct {
+	count    int
+	sql      string
+	callers  map[string]int
+	reported bool
+}
+
+// nPlusOnePlugin 统计请求内的查询形状，发现重复查询时输出 warn 日志。
+type nPlusOnePlugin struct {
+	threshold  int
+	sampleRate float64
+}
+
+// newNPlusOnePlugin 根据配置构造 N+1 检测插件。
+func newNPlusOnePlugin(c NPlusOneConf) *nPlusOnePlugin {
+	p := &nPlusOnePlugin{threshold: c.Threshold, sampleRate: c.SampleRate}
+	if p.threshold <= 0 {
+		p.threshold = defaultNPlusOneThreshold
+	}
+	if p.sampleRate <= 0 || p.sampleRate > 1 {
+		p.sampleRate = 1
+	}
+	return p
+}
+
+// Name 实现 gorm.Plugin。
+func (p *nPlusOnePlugin) Name() string {
+	return "gormx:n_plus_one"
+}
+
+// Initialize 实现 gorm.Plugin，在查询执行后统计。
+func (p *nPlusOnePlugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().After("*").Register(p.Name(), p.after); err != nil {
+		return err
+	}
+	return db.Callback().Row().After("*").Register(p.Name(), p.after)
+}
+
+// after 记录本次查询，首次达到阈值时输出 warn 日志。
+func (p *nPlusOnePlugin) after(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Context == nil || stmt.SQL.Len() == 0 {
+		return
+	}
+	tracker, ok := stmt.Context.Value(queryTrackerKey{}).(*queryTracker)
+	if !ok {
+		return
+	}
+	tracker.sampleOnce.Do(func() {
+		tracker.sampled = p.sampleRate >= 1 || rand.Float64() < p.sampleRate
+	})
+	if !tracker.sampled {
+		return
+	}
+
+	sql := stmt.SQL.String()
+	fingerprint := internal.SQLFingerprint(sql)
+	caller := internal.Caller()
+
+	tracker.mu.Lock()
+	shape, ok := tracker.shapes[fingerprint]
+	if !ok {
+		shape = &queryShape{sql: sql, callers: make(map[string]int)}
+		tracker.shapes[fingerprint] = shape
+	}
+	shape.count++
+	if _, ok = shape.callers[caller]; ok || len(shape.callers) < maxTrackedCallers {
+		shape.callers[caller]++
+	}
+	report := shape.count >= p.threshold && !shape.reported
+	if report {
+		shape.reported = true
+	}
+	count, callers := shape.count, formatCallers(shape.callers)
+	tracker.mu.Unlock()
+
+	if report {
+		db.Logger.Warn(stmt.Context, "N+1 query detected: %d identical queries in one request (fingerprint %s)\n%s\ncall sites: %s",
+			count, fingerprint, internal.NormalizeSQL(sql), callers)
+	}
+}
+
+// formatCallers 按次数降序输出调用点。
+func formatCallers(callers map[string]int) string {
+	sites := make([]string, 0, len(callers))
+	for site := range callers {
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		return callers[sites[i]] > callers[sites[j]]
+	})
+
+	parts := make([]string, 0, len(sites))
+	for _, site := range sites {
+		parts = append(parts, site+" x"+strconv.Itoa(callers[site]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,123 @@
+package gormx
+
+import (
+	"github.com/fireflycore/gormx/internal"
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+	"gorm.io/gorm"
+)
+
+// usePlugins 挂载 gormx 内置插件（Tracing、Statement 注入以及按配置启用的检测插件）。
+func usePlugins(db *gorm.DB, c *Conf) error {
+	// sqlcommenter 注释随请求变化，会使预处理语句缓存失效，二者不能同时开启。
+	if c.SqlCommenter != nil && c.PrepareStmt {
+		return errSqlCommenterPrepareStmt
+	}
+
+	// 启用 otelgorm 插件（Tracing），可通过 Conf.Tracing 关闭或调整。
+	// 插件内部会检查 TracerProvider，如果没有注册则只会产生空操作，开销极小。
+	if !c.Tracing.Disable {
+		if err := db.Use(otelgorm.NewPlugin(c.tracingOptions()...)); err != nil {
+			return err
+		}
+	}
+
+	// 注入当前 Statement，便于 logger 记录主表名等信息。
+	if err := db.Use(internal.StatementPlugin{}); err != nil {
+		return err
+	}
+
+	// 为 gormx:"id:<name>" 标签的字段在创建时生成 ID。
+	if err := db.Use(newIDPlugin(c)); err != nil {
+		return err
+	}
+
+	// 按请求上下文中的用户 ID 填充 gormx:"created_by/updated_by/deleted_by" 审计字段。
+	if err := db.Use(newAuditPlugin(c)); err != nil {
+		return err
+	}
+
+	// 按请求上下文中的租户 ID 过滤与填充 gormx:"tenant" 租户字段。
+	if err := db.Use(newTenantPlugin(c)); err != nil {
+		return err
+	}
+
+	// 为 gormx:"blind_index:<字段名>" 标签的列写入加密字段的盲索引。
+	if err := db.Use(&blindIndexPlugin{}); err != nil {
+		return err
+	}
+
+	// 加密 map 中的 Encrypted 值，需在盲索引之后。
+	if err := db.Use(encryptPlugin{}); err != nil {
+		return err
+	}
+
+	// 校验 Version 字段的乐观锁冲突。
+	if err := db.Use(versionPlugin{}); err != nil {
+		return err
+	}
+
+	// 核算 WithBudget 挂载的请求查询预算（未挂载时仅一次 context 查找）。
+	if err := db.Use(budgetPlugin{}); err != nil {
+		return err
+	}
+
+	// 按配置启用 N+1 查询检测。
+	if c.NPlusOne != nil {
+		if err := db.Use(newNPlusOnePlugin(*c.NPlusOne)); err != nil {
+			return err
+		}
+	}
+
+	// 按配置为每条 SQL 追加 sqlcommenter 注释。
+	if c.SqlCommenter != nil {
+		pool := wrapConnPool(db)
+		pool.rewriters = append(pool.rewriters, newSqlCommenter(*c.SqlCommenter, c.metadataExtractor()))
+	}
+
+	// 按配置启用事务级追踪（begin/commit/rollback、慢事务与事务 Span）。
+	if c.Transaction != nil {
+		if err := useTransactionTracer(db, c); err != nil {
+			return err
+		}
+	}
+
+	// 按配置启用连接与事务泄漏检测（调试模式）。
+	if c.LeakDetector != nil {
+		if err := useLeakDetector(db, *c.LeakDetector); err != nil {
+			return err
+		}
+	}
+
+	// 按配置将租户与用户写入 Postgres 会话设置（行级安全策略）。
+	if c.RowLevelSecurity != nil {
+		if err := useRowLevelSecurity(db, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registerAround 为所有回调链注册前置（最先执行）与后置（最后执行）回调。
+func registerAround(db *gorm.DB, name string, before, after func(*gorm.DB)) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("*").Register(name+":before", before),
+		cb.Query().Before("*").Register(name+":before", before),
+		cb.Update().Before("*").Register(name+":before", before),
+		cb.Delete().Before("*").Register(name+":before", before),
+		cb.Row().Before("*").Register(name+":before", before),
+		cb.Raw().Before("*").Register(name+":before", before),
+		cb.Create().After("*").Register(name+":after", after),
+		cb.Query().After("*").Register(name+":after", after),
+		cb.Update().After("*").Register(name+":after", after),
+		cb.Delete().After("*").Register(name+":after", after),
+		cb.Row().After("*").Register(name+":after", after),
+		cb.Raw().After("*").Register(name+":after", after),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/fireflycore/go-utils/tlsx"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/stdlib"
-	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -79,8 +78,14 @@ func NewPostgres(mc *PostgresConf, tables []interface{}) (*PostgresDB, error) {
 		connConfig.TLSConfig.ServerName = host
 	}
 
+	// RLS 的 statement 模式写入会话级设置，连接再次取出时需要先清空。
+	var opts []stdlib.OptionOpenDB
+	if mc.RowLevelSecurity != nil && mc.RowLevelSecurity.withDefaults().Mode == RowLevelSecurityStatement {
+		opts = append(opts, stdlib.OptionResetSession(mc.RowLevelSecurity.resetSession))
+	}
+
 	// 使用 pgx stdlib 将 ConnConfig 转成 *sql.DB，交给 gorm driver 复用连接池。
-	sqlDB := stdlib.OpenDB(*connConfig)
+	sqlDB := stdlib.OpenDB(*connConfig, opts...)
 
 	// log 根据配置构造（默认丢弃输出，开启 Logger 时输出）。
 	log := NewLogger(&mc.Conf)
@@ -114,8 +119,8 @@ func NewPostgres(mc *PostgresConf, tables []interface{}) (*PostgresDB, error) {
 		return nil, err
 	}
 
-	// 启用 otelgorm 插件（Tracing）
-	if err = db.Use(otelgorm.NewPlugin(otelgorm.WithDBName(mc.Database))); err != nil {
+	// 挂载 gormx 内置插件（Tracing、Statement 注入以及按配置启用的检测插件）。
+	if err = usePlugins(db, &mc.Conf); err != nil {
 		return nil, err
 	}
 
@@ -125,6 +130,16 @@ func NewPostgres(mc *PostgresConf, tables []interface{}) (*PostgresDB, error) {
 		if err = db.AutoMigrate(tables...); err != nil {
 			return nil, err
 		}
+		// 创建 gormx:"unique" 标签声明的复合唯一索引（含 deleted_at）。
+		if err = MigrateUniqueIndexes(db, tables...); err != nil {
+			return nil, err
+		}
+		// 为租户模型启用行级安全策略。
+		if mc.RowLevelSecurity != nil {
+			if err = MigrateRowLevelSecurity(db, *mc.RowLevelSecurity, tables...); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// 获取底层 *sql.DB 以配置连接池参数。
@@ -0,0 +1,365 @@
+package gormx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// RetentionPurge 物理删除超过保留期的软删除记录。
+	RetentionPurge = "purge"
+	// RetentionArchive 将超过保留期的软删除记录移动到 <table>_archive 表。
+	RetentionArchive = "archive"
+)
+
+const (
+	// defaultRetentionBatchSize 为默认的每批处理行数。
+	defaultRetentionBatchSize = 500
+	// defaultRetentionBatchInterval 为默认的批次间隔。
+	defaultRetentionBatchInterval = 100 * time.Millisecond
+	// defaultRetentionInterval 为 Start 默认的执行间隔。
+	defaultRetentionInterval = time.Hour
+	// archiveTableSuffix 为归档表名后缀。
+	archiveTableSuffix = "_archive"
+)
+
+// errRetentionRunning 表示上一轮清理尚未结束。
+var errRetentionRunning = errors.New("gormx: retention job is already running")
+
+// RetentionConf 为软删除记录保留期清理任务的配置。
+type RetentionConf struct {
+	// 处理方式（purge/archive，默认 purge）
+	// Mode 为 purge 时物理删除，为 archive 时在一个事务中写入 <table>_archive 后删除。
+	Mode string `json:"mode"`
+	// 默认保留期（如 30d、720h）
+	// Retention 对没有 gormx:"retention" 标签且未在 Tables 中配置的模型生效，为空时跳过这些模型。
+	Retention string `json:"retention"`
+	// 按表名配置的保留期，优先于模型标签
+	Tables map[string]string `json:"tables"`
+	// 每批处理行数（默认500）
+	BatchSize int `json:"batch_size"`
+	// 批次间隔（毫秒，默认100）
+	// BatchInterval 用于限流，避免长时间占用连接与产生复制延迟。
+	BatchInterval int `json:"batch_interval"`
+	// 执行间隔（秒，默认3600），仅 Start 使用
+	Interval int `json:"interval"`
+	// 只统计待处理的行数，不删除也不归档
+	DryRun bool `json:"dry_run"`
+}
+
+// RetentionStats 为一个模型的清理进度与累计统计。
+type RetentionStats struct {
+	// Table 为表名。
+	Table string `json:"table"`
+	// Retention 为保留期，Cutoff 为最近一轮的截止时间（早于该时间删除的记录会被处理）。
+	Retention time.Duration `json:"retention"`
+	Cutoff    time.Time     `json:"cutoff"`
+	// Running 表示该表正在处理。
+	Running bool `json:"running"`
+	// Pending 为最近一轮 dry-run 统计的待处理行数。
+	Pending int64 `json:"pending"`
+	// Purged、Archived、Batches 为累计物理删除行数、归档行数与批次数。
+	Purged   int64 `json:"purged"`
+	Archived int64 `json:"archived"`
+	Batches  int64 `json:"batches"`
+	// LastRun 为最近一轮的开始时间，LastDuration 为其耗时，LastError 为其错误。
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+}
+
+// retentionModel 为一个注册到清理任务的模型。
+type retentionModel struct {
+	model     interface{}
+	schema    *schema.Schema
+	sd        internal.SoftDelete
+	retention time.Duration
+}
+
+// RetentionJob 定期清理（或归档）软删除超过保留期的记录。
+type RetentionJob struct {
+	db            *gorm.DB
+	mode          string
+	dryRun        bool
+	batchSize     int
+	batchInterval time.Duration
+	interval      time.Duration
+	models        []retentionModel
+	// archives 记录本任务已检查过的归档表，由 running 保护。
+	archives map[string]bool
+
+	running sync.Mutex
+	mu      sync.Mutex
+	stats   []RetentionStats
+}
+
+// NewRetentionJob 为 models 构造清理任务，保留期依次取 conf.Tables[表名]、模型的 gormx:"retention" 标签与 conf.Retention，
+// 都没有时跳过该模型；模型必须带软删除字段，0/1 标记模式需要配置 DeletedAtField 记录删除时间。
+func NewRetentionJob(db *gorm.DB, conf RetentionConf, models ...interface{}) (*RetentionJob, error) {
+	j := &RetentionJob{
+		db:            db,
+		mode:          conf.Mode,
+		dryRun:        conf.DryRun,
+		batchSize:     conf.BatchSize,
+		batchInterval: time.Millisecond * time.Duration(conf.BatchInterval),
+		interval:      time.Second * time.Duration(conf.Interval),
+		archives:      make(map[string]bool),
+	}
+	if j.mode == "" {
+		j.mode = RetentionPurge
+	}
+	if j.mode != RetentionPurge && j.mode != RetentionArchive {
+		return nil, fmt.Errorf("gormx: unknown retention mode %q", conf.Mode)
+	}
+	if j.batchSize <= 0 {
+		j.batchSize = defaultRetentionBatchSize
+	}
+	if j.batchInterval <= 0 {
+		j.batchInterval = defaultRetentionBatchInterval
+	}
+	if j.interval <= 0 {
+		j.interval = defaultRetentionInterval
+	}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		s := stmt.Schema
+		sd, ok := internal.ParseSoftDelete(s)
+		if !ok {
+			return nil, fmt.Errorf("gormx: retention: %s has no soft delete field", s.Table)
+		}
+		if sd.Mode == internal.SoftDeleteFlag && sd.DeletedAtField == nil {
+			return nil, fmt.Errorf("gormx: retention: %s: %w", s.Table, internal.ErrNoDeletionTime)
+		}
+
+		retention, ok, err := internal.Retention(s)
+		if value := conf.Tables[s.Table]; value != "" {
+			retention, err = internal.ParseRetention(value)
+			ok = err == nil
+		} else if !ok && err == nil && conf.Retention != "" {
+			retention, err = internal.ParseRetention(conf.Retention)
+			ok = err == nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gormx: retention: %s: %w", s.Table, err)
+		}
+		if !ok {
+			continue
+		}
+
+		j.models = append(j.models, retentionModel{model: model, schema: s, sd: sd, retention: retention})
+		j.stats = append(j.stats, RetentionStats{Table: s.Table, Retention: retention})
+	}
+	return j, nil
+}
+
+// Start 立即执行一轮清理，之后按 Interval 定期执行，直到 ctx 取消；错误记录到 db 的日志中。
+func (j *RetentionJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			if err := j.Run(ctx); err != nil && ctx.Err() == nil {
+				j.db.Logger.Error(ctx, "retention job failed: %s", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run 对所有模型执行一轮清理，单个模型出错不影响其它模型，返回合并后的错误；上一轮未结束时直接返回错误。
+// 清理跨租户执行（SkipTenant），对开启了 RLS 的表需要使用不受策略限制的数据库账号。
+func (j *RetentionJob) Run(ctx context.Context) error {
+	if !j.running.TryLock() {
+		return errRetentionRunning
+	}
+	defer j.running.Unlock()
+
+	ctx = SkipTenant(ctx)
+	var errs []error
+	for i, m := range j.models {
+		if err := j.runModel(ctx, i, m); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.schema.Table, err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats 返回每个模型的清理进度与累计统计，可在运行中调用。
+func (j *RetentionJob) Stats() []RetentionStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]RetentionStats(nil), j.stats...)
+}
+
+// update 在锁内修改第 i 个模型的统计。
+func (j *RetentionJob) update(i int, fn func(s *RetentionStats)) {
+	j.mu.Lock()
+	fn(&j.stats[i])
+	j.mu.Unlock()
+}
+
+// runModel 分批处理一个模型，每批先按截止时间查出主键，再删除（或归档后删除）这些记录。
+func (j *RetentionJob) runModel(ctx context.Context, i int, m retentionModel) (err error) {
+	start := time.Now()
+	cutoff := start.Add(-m.retention)
+	j.update(i, func(s *RetentionStats) {
+		s.Running, s.Cutoff, s.LastRun, s.LastError = true, cutoff, start, ""
+	})
+	defer func() {
+		j.update(i, func(s *RetentionStats) {
+			s.Running, s.LastDuration = false, time.Since(start)
+			if err != nil {
+				s.LastError = err.Error()
+			}
+		})
+	}()
+
+	expired, err := m.sd.DeletedBefore(cutoff)
+	if err != nil {
+		return err
+	}
+	db := j.db.WithContext(ctx)
+
+	if j.dryRun {
+		var pending int64
+		if err = db.Model(m.model).Unscoped().Where(expired).Count(&pending).Error; err != nil {
+			return err
+		}
+		j.update(i, func(s *RetentionStats) { s.Pending = pending })
+		j.db.Logger.Info(ctx, "retention dry run: %s has %d rows deleted before %s", m.schema.Table, pending, cutoff.Format(time.RFC3339))
+		return nil
+	}
+
+	if j.mode == RetentionArchive {
+		if err = j.ensureArchive(db, m); err != nil {
+			return err
+		}
+	}
+
+	var total int64
+	for {
+		var rows []map[string]interface{}
+		err = db.Model(m.model).Unscoped().Select(m.schema.PrimaryFieldDBNames).Where(expired).
+			Order(primaryKeyOrder(m.schema)).Limit(j.batchSize).Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			break
+		}
+
+		// 再次带上过期条件，跳过查询后被恢复的记录。
+		where := clause.And(expired, internal.PrimaryKeys(m.schema.PrimaryFieldDBNames, rows))
+		var affected int64
+		if j.mode == RetentionArchive {
+			affected, err = j.archive(db, m, where)
+		} else {
+			result := db.Unscoped().Where(where).Delete(reflect.New(m.schema.ModelType).Interface())
+			affected, err = result.RowsAffected, result.Error
+		}
+		if err != nil {
+			break
+		}
+
+		total += affected
+		j.update(i, func(s *RetentionStats) {
+			s.Batches++
+			if j.mode == RetentionArchive {
+				s.Archived += affected
+			} else {
+				s.Purged += affected
+			}
+		})
+		if len(rows) < j.batchSize {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(j.batchInterval):
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if total > 0 {
+		j.db.Logger.Info(ctx, "retention %s: %s %d rows deleted before %s", j.mode, m.schema.Table, total, cutoff.Format(time.RFC3339))
+	}
+	return err
+}
+
+// archive 在一个事务中将匹配的记录写入归档表并删除，返回删除的行数。
+func (j *RetentionJob) archive(db *gorm.DB, m retentionModel, where clause.Expression) (int64, error) {
+	vars := make([]interface{}, len(m.schema.DBNames))
+	for i, name := range m.schema.DBNames {
+		vars[i] = clause.Column{Name: name}
+	}
+	columns := clause.Expr{SQL: strings.TrimSuffix(strings.Repeat("?,", len(vars)), ","), Vars: vars}
+
+	var affected int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table(m.schema.Table).Exec("INSERT INTO ? (?) SELECT ? FROM ? WHERE ?",
+			clause.Table{Name: m.schema.Table + archiveTableSuffix}, columns, columns, clause.Table{Name: m.schema.Table}, where).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where(where).Delete(reflect.New(m.schema.ModelType).Interface())
+		affected = result.RowsAffected
+		return result.Error
+	})
+	return affected, err
+}
+
+// ensureArchive 创建归档表（只复制列，不复制索引与约束，同一业务键可以多次归档），并补齐模型新增的列；每个任务只检查一次。
+func (j *RetentionJob) ensureArchive(db *gorm.DB, m retentionModel) error {
+	archive := m.schema.Table + archiveTableSuffix
+	if j.archives[archive] {
+		return nil
+	}
+
+	migrator := db.Table(archive).Migrator()
+	if !migrator.HasTable(archive) {
+		if err := db.Exec("CREATE TABLE ? AS SELECT * FROM ? WHERE 1 = 0", clause.Table{Name: archive}, clause.Table{Name: m.schema.Table}).Error; err != nil {
+			return err
+		}
+	} else {
+		for _, name := range m.schema.DBNames {
+			if !migrator.HasColumn(m.model, name) {
+				if err := migrator.AddColumn(m.model, name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	j.archives[archive] = true
+	return nil
+}
+
+// primaryKeyOrder 返回按主键排序的子句，保证分批顺序稳定。
+func primaryKeyOrder(s *schema.Schema) clause.OrderBy {
+	var order clause.OrderBy
+	for _, name := range s.PrimaryFieldDBNames {
+		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: name}})
+	}
+	return order
+}
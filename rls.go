@@ -0,0 +1,172 @@
+package gormx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fireflycore/gormx/internal"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// RowLevelSecurityTransaction 仅在事务开始时写入会话设置（SET LOCAL），事务外的语句不写入。
+	RowLevelSecurityTransaction = "transaction"
+	// RowLevelSecurityStatement 在事务开始时以及事务外的每条语句前写入会话设置。
+	RowLevelSecurityStatement = "statement"
+)
+
+const (
+	// defaultTenantSetting 为默认的租户 ID 会话设置名。
+	defaultTenantSetting = "app.tenant_id"
+	// defaultUserSetting 为默认的用户 ID 会话设置名。
+	defaultUserSetting = "app.user_id"
+	// rowLevelSecurityPolicy 为 gormx 创建的租户隔离策略名。
+	rowLevelSecurityPolicy = "gormx_tenant_isolation"
+)
+
+var (
+	// errRowLevelSecurityDialect 表示行级安全策略仅支持 Postgres。
+	errRowLevelSecurityDialect = errors.New("gormx: row level security requires postgres")
+	// errRowLevelSecurityPrepareStmt 表示 statement 模式不能与预处理语句缓存同时开启。
+	errRowLevelSecurityPrepareStmt = errors.New("gormx: row level security statement mode cannot be used with PrepareStmt")
+)
+
+// RowLevelSecurityConf 为 Postgres 行级安全策略（RLS）的配置。
+type RowLevelSecurityConf struct {
+	// 写入时机（transaction/statement，默认 transaction）
+	// Mode 为 transaction 时只在事务开始时执行 SET LOCAL，为 statement 时事务外的语句在独占连接上先写入会话设置。
+	Mode string `json:"mode"`
+	// 租户 ID 的会话设置名（默认 app.tenant_id）
+	TenantSetting string `json:"tenant_setting"`
+	// 用户 ID 的会话设置名（默认 app.user_id）
+	UserSetting string `json:"user_setting"`
+}
+
+// withDefaults 返回填充默认值后的配置。
+func (c RowLevelSecurityConf) withDefaults() RowLevelSecurityConf {
+	if c.Mode == "" {
+		c.Mode = RowLevelSecurityTransaction
+	}
+	if c.TenantSetting == "" {
+		c.TenantSetting = defaultTenantSetting
+	}
+	if c.UserSetting == "" {
+		c.UserSetting = defaultUserSetting
+	}
+	return c
+}
+
+// validate 校验配置与当前连接是否兼容。
+func (c RowLevelSecurityConf) validate(db *gorm.DB, conf *Conf) error {
+	if db.Dialector.Name() != "postgres" {
+		return errRowLevelSecurityDialect
+	}
+	switch c.Mode {
+	case RowLevelSecurityTransaction:
+	case RowLevelSecurityStatement:
+		if conf.PrepareStmt {
+			return errRowLevelSecurityPrepareStmt
+		}
+	default:
+		return fmt.Errorf("gormx: unknown row level security mode %q", c.Mode)
+	}
+	return nil
+}
+
+// rowLevelSecurity 将请求上下文中的租户 ID 与用户 ID 写入 Postgres 会话设置，供 RLS 策略读取。
+type rowLevelSecurity struct {
+	conf      RowLevelSecurityConf
+	extractor MetadataExtractor
+}
+
+// useRowLevelSecurity 为 db 挂载 RLS 会话设置。
+func useRowLevelSecurity(db *gorm.DB, c *Conf) error {
+	conf := c.RowLevelSecurity.withDefaults()
+	if err := conf.validate(db, c); err != nil {
+		return err
+	}
+
+	r := &rowLevelSecurity{conf: conf, extractor: c.metadataExtractor()}
+	pool := wrapConnPool(db)
+	pool.txSetups = append(pool.txSetups, r.setup)
+	if conf.Mode == RowLevelSecurityStatement {
+		pool.stmtSetups = append(pool.stmtSetups, r.setup)
+	}
+	return nil
+}
+
+// setup 实现 sessionSetup：set_config 与 SET [LOCAL] 等价，但可以使用参数绑定。
+// SkipTenant 不会绕过 RLS，此时写入空值，由策略拒绝访问租户数据。
+func (r *rowLevelSecurity) setup(ctx context.Context, conn gorm.ConnPool, local bool) error {
+	var tenantId string
+	if !isSkipTenant(ctx) {
+		tenantId = tenantFromContext(ctx, r.extractor)
+	}
+	userId := r.extractor.Extract(ctx).UserId
+
+	_, err := conn.ExecContext(ctx, "SELECT set_config($1, $2, $3), set_config($4, $5, $3)",
+		r.conf.TenantSetting, tenantId, local, r.conf.UserSetting, userId)
+	return err
+}
+
+// resetSession 在连接被再次取出时清空会话级设置（statement 模式），
+// 避免直接使用 *sql.DB 的语句读到上一个请求的租户；失败时丢弃该连接。
+func (c RowLevelSecurityConf) resetSession(ctx context.Context, conn *pgx.Conn) error {
+	c = c.withDefaults()
+	if _, err := conn.Exec(ctx, "SELECT set_config($1, '', false), set_config($2, '', false)", c.TenantSetting, c.UserSetting); err != nil {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// RowLevelSecurityPolicies 返回为租户模型（gormx:"tenant"）启用 RLS 的 DDL，非租户模型跳过。
+// 策略要求 tenant_id 等于会话设置中的租户 ID（未设置或为空时不可见、不可写），并对表的 owner 同样生效。
+func RowLevelSecurityPolicies(db *gorm.DB, conf RowLevelSecurityConf, models ...interface{}) ([]string, error) {
+	conf = conf.withDefaults()
+	setting := "NULLIF(current_setting('" + strings.ReplaceAll(conf.TenantSetting, "'", "''") + "', true), '')"
+
+	var ddl []string
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		field := internal.TenantField(stmt.Schema)
+		if field == nil {
+			continue
+		}
+
+		table := stmt.Quote(clause.Table{Name: stmt.Table})
+		policy := stmt.Quote(rowLevelSecurityPolicy)
+		condition := stmt.Quote(field.DBName) + " = " + setting
+		ddl = append(ddl,
+			"ALTER TABLE "+table+" ENABLE ROW LEVEL SECURITY",
+			"ALTER TABLE "+table+" FORCE ROW LEVEL SECURITY",
+			"DROP POLICY IF EXISTS "+policy+" ON "+table,
+			"CREATE POLICY "+policy+" ON "+table+" USING ("+condition+") WITH CHECK ("+condition+")",
+		)
+	}
+	return ddl, nil
+}
+
+// MigrateRowLevelSecurity 在一个事务中为租户模型启用 RLS 并（重新）创建租户隔离策略，
+// NewPostgres 开启 AutoMigrate 且配置了 RowLevelSecurity 时会自动调用。
+func MigrateRowLevelSecurity(db *gorm.DB, conf RowLevelSecurityConf, models ...interface{}) error {
+	ddl, err := RowLevelSecurityPolicies(db, conf, models...)
+	if err != nil || len(ddl) == 0 {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, sql := range ddl {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,407 @@
+package gormx
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// defaultTenantMaxPools 为默认同时保持的租户独立连接池数量。
+	defaultTenantMaxPools = 16
+)
+
+var (
+	// ErrTenantConnectionLimit 表示租户连接池的连接数之和将超过 TenantRouterConf.MaxConnections，可通过 errors.Is 判断。
+	ErrTenantConnectionLimit = errors.New("gormx: tenant connection limit exceeded")
+	// errTenantRouterClosed 表示 TenantRouter 已关闭。
+	errTenantRouterClosed = errors.New("gormx: tenant router is closed")
+)
+
+// TenantTarget 为租户的物理隔离目标，Schema 与 Conf 至多设置一个，都为空时使用默认连接池（行级多租户）。
+type TenantTarget struct {
+	// Schema 为 Postgres schema 名，租户共享默认连接池，通过 search_path 切换。
+	Schema string
+	// Conf 为租户独立数据库的配置（Type 为 Postgres 或 Mysql），连接池按需创建。
+	Conf *Conf
+}
+
+// TenantResolver 将租户 ID 解析为物理隔离目标。
+type TenantResolver interface {
+	Resolve(ctx context.Context, tenantId string) (TenantTarget, error)
+}
+
+// TenantResolverFunc 为函数形式的 TenantResolver。
+type TenantResolverFunc func(ctx context.Context, tenantId string) (TenantTarget, error)
+
+// Resolve 实现 TenantResolver。
+func (f TenantResolverFunc) Resolve(ctx context.Context, tenantId string) (TenantTarget, error) {
+	return f(ctx, tenantId)
+}
+
+// TenantRouterConf 为租户路由的配置。
+type TenantRouterConf struct {
+	// 同时保持的租户独立连接池数量上限（默认16）
+	// MaxPools 超过时关闭最久未使用的连接池，下次访问时重新创建。
+	MaxPools int `json:"max_pools"`
+	// 所有连接池（含默认连接池）的最大连接数之和上限，0表示不限制
+	// MaxConnections 按各连接池的 MaxOpenConnects 计算，开启时租户 Conf 必须设置 MaxOpenConnects。
+	MaxConnections int `json:"max_connections"`
+
+	// extractor 从请求上下文中提取租户 ID，为空时读取 gRPC metadata。
+	extractor MetadataExtractor
+}
+
+// WithMetadataExtractor 设置提取租户 ID 的方式（默认读取 gRPC metadata，WithTenant 指定的值优先）。
+func (c *TenantRouterConf) WithMetadataExtractor(extractor MetadataExtractor) {
+	c.extractor = extractor
+}
+
+// TenantRouter 按请求上下文中的租户 ID 将语句路由到租户的 schema 或独立数据库。
+type TenantRouter struct {
+	db        *gorm.DB
+	resolver  TenantResolver
+	extractor MetadataExtractor
+	maxPools  int
+	maxConns  int
+
+	mu sync.Mutex
+	// pools 为租户 ID 到 lru 元素的索引，lru 头部为最近使用的连接池。
+	pools map[string]*list.Element
+	lru   *list.List
+	// conns 为已分配的连接数（含默认连接池）。
+	conns  int
+	closed bool
+}
+
+// tenantPool 为一个租户的独立连接池。
+type tenantPool struct {
+	tenantId string
+	db       *gorm.DB
+	err      error
+	conns    int
+	// ready 在连接池创建完成（或失败）后关闭。
+	ready chan struct{}
+}
+
+// NewTenantRouter 基于默认连接池 db（NewPostgres/NewMysql 返回的 DB）构造租户路由，c 为空时使用默认配置。
+func NewTenantRouter(c *TenantRouterConf, db *gorm.DB, resolver TenantResolver) (*TenantRouter, error) {
+	if c == nil {
+		c = &TenantRouterConf{}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &TenantRouter{
+		db:        db,
+		resolver:  resolver,
+		extractor: c.extractor,
+		maxPools:  c.MaxPools,
+		maxConns:  c.MaxConnections,
+		pools:     make(map[string]*list.Element),
+		lru:       list.New(),
+		conns:     sqlDB.Stats().MaxOpenConnections,
+	}
+	if r.extractor == nil {
+		r.extractor = NewGRPCMetadataExtractor()
+	}
+	if r.maxPools <= 0 {
+		r.maxPools = defaultTenantMaxPools
+	}
+	return r, nil
+}
+
+// resolve 返回上下文中的租户 ID 与其物理隔离目标。
+func (r *TenantRouter) resolve(ctx context.Context) (string, TenantTarget, error) {
+	tenantId := tenantFromContext(ctx, r.extractor)
+	if tenantId == "" {
+		return "", TenantTarget{}, ErrMissingTenant
+	}
+	target, err := r.resolver.Resolve(ctx, tenantId)
+	if err != nil {
+		return "", TenantTarget{}, err
+	}
+	switch {
+	case target.Schema != "" && target.Conf != nil:
+		return "", TenantTarget{}, fmt.Errorf("gormx: tenant %s resolves to both schema and conf", tenantId)
+	case target.Schema != "" && r.db.Dialector.Name() != "postgres":
+		return "", TenantTarget{}, fmt.Errorf("gormx: tenant %s: schema routing requires postgres", tenantId)
+	}
+	return tenantId, target, nil
+}
+
+// DB 返回租户独立数据库（或默认连接池）的 *gorm.DB；schema 租户需要固定连接，请使用 Transaction 或 Connection。
+func (r *TenantRouter) DB(ctx context.Context) (*gorm.DB, error) {
+	tenantId, target, err := r.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case target.Schema != "":
+		return nil, fmt.Errorf("gormx: tenant %s uses schema %q, use TenantRouter.Transaction or Connection", tenantId, target.Schema)
+	case target.Conf != nil:
+		db, err := r.pool(tenantId, target.Conf)
+		if err != nil {
+			return nil, err
+		}
+		return db.WithContext(ctx), nil
+	default:
+		return r.db.WithContext(ctx), nil
+	}
+}
+
+// Transaction 在租户的事务中执行 fc，schema 租户在事务开始后执行 SET LOCAL search_path。
+func (r *TenantRouter) Transaction(ctx context.Context, fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	tenantId, target, err := r.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case target.Schema != "":
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT set_config('search_path', ?, true)", quoteSchema(target.Schema)).Error; err != nil {
+				return err
+			}
+			return fc(tx)
+		}, opts...)
+	case target.Conf != nil:
+		db, err := r.pool(tenantId, target.Conf)
+		if err != nil {
+			return err
+		}
+		return db.WithContext(ctx).Transaction(fc, opts...)
+	default:
+		return r.db.WithContext(ctx).Transaction(fc, opts...)
+	}
+}
+
+// Connection 在租户的连接上执行 fc：schema 租户固定一个连接并设置会话级 search_path，结束时还原；
+// 独立数据库与默认连接池不固定连接。
+func (r *TenantRouter) Connection(ctx context.Context, fc func(db *gorm.DB) error) error {
+	tenantId, target, err := r.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case target.Schema != "":
+		return r.schemaConnection(ctx, target.Schema, fc)
+	case target.Conf != nil:
+		db, err := r.pool(tenantId, target.Conf)
+		if err != nil {
+			return err
+		}
+		return fc(db.WithContext(ctx))
+	default:
+		return fc(r.db.WithContext(ctx))
+	}
+}
+
+// schemaConnection 固定默认连接池中的一个连接，设置 search_path 后执行 fc；
+// 还原失败时丢弃该连接，避免其它请求使用到该租户的 search_path。
+func (r *TenantRouter) schemaConnection(ctx context.Context, schema string, fc func(db *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Connection(func(tx *gorm.DB) (err error) {
+		conn, ok := tx.Statement.ConnPool.(*sql.Conn)
+		if !ok {
+			return gorm.ErrInvalidDB
+		}
+		if pool, ok := r.db.ConnPool.(*connPool); ok {
+			pinned, err := pool.pin(ctx, conn)
+			if err != nil {
+				return err
+			}
+			tx.Statement.ConnPool = pinned
+		}
+
+		if _, err = conn.ExecContext(ctx, "SELECT set_config('search_path', $1, false)", quoteSchema(schema)); err != nil {
+			return err
+		}
+		defer func() {
+			if _, resetErr := conn.ExecContext(context.WithoutCancel(ctx), "RESET search_path"); resetErr != nil {
+				_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+		}()
+
+		return fc(tx)
+	})
+}
+
+// ForEach 依次在每个租户的连接上执行 fc（见 Connection），ctx 中的租户 ID 由 WithTenant 指定，遇到错误立即返回。
+func (r *TenantRouter) ForEach(ctx context.Context, tenantIds []string, fc func(ctx context.Context, db *gorm.DB) error) error {
+	for _, tenantId := range tenantIds {
+		tenantCtx := WithTenant(ctx, tenantId)
+		if err := r.Connection(tenantCtx, func(db *gorm.DB) error {
+			return fc(tenantCtx, db)
+		}); err != nil {
+			return fmt.Errorf("gormx: tenant %s: %w", tenantId, err)
+		}
+	}
+	return nil
+}
+
+// Migrate 为每个租户执行 AutoMigrate 与 MigrateUniqueIndexes，schema 租户会先创建 schema（已存在则跳过）。
+// 默认连接池（行级多租户）的租户会重复迁移同一组表，由 AutoMigrate 保证幂等。
+func (r *TenantRouter) Migrate(ctx context.Context, tenantIds []string, models ...interface{}) error {
+	for _, tenantId := range tenantIds {
+		_, target, err := r.resolve(WithTenant(ctx, tenantId))
+		if err != nil {
+			return fmt.Errorf("gormx: tenant %s: %w", tenantId, err)
+		}
+		if target.Schema != "" {
+			if err = r.db.WithContext(ctx).Exec("CREATE SCHEMA IF NOT EXISTS ?", clause.Table{Name: target.Schema}).Error; err != nil {
+				return fmt.Errorf("gormx: tenant %s: %w", tenantId, err)
+			}
+		}
+	}
+
+	return r.ForEach(ctx, tenantIds, func(_ context.Context, db *gorm.DB) error {
+		if err := db.AutoMigrate(models...); err != nil {
+			return err
+		}
+		return MigrateUniqueIndexes(db, models...)
+	})
+}
+
+// Close 关闭所有租户独立连接池（不关闭默认连接池）。
+func (r *TenantRouter) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	var pools []*tenantPool
+	for e := r.lru.Front(); e != nil; e = e.Next() {
+		pools = append(pools, e.Value.(*tenantPool))
+	}
+	r.pools = make(map[string]*list.Element)
+	r.lru.Init()
+	r.mu.Unlock()
+
+	var errs []error
+	for _, p := range pools {
+		<-p.ready
+		errs = append(errs, p.close())
+	}
+	return errors.Join(errs...)
+}
+
+// pool 返回租户的独立连接池，不存在时创建；超过连接池数量或连接数上限时先关闭最久未使用的连接池。
+func (r *TenantRouter) pool(tenantId string, conf *Conf) (*gorm.DB, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errTenantRouterClosed
+	}
+	if e, ok := r.pools[tenantId]; ok {
+		r.lru.MoveToFront(e)
+		r.mu.Unlock()
+		p := e.Value.(*tenantPool)
+		<-p.ready
+		return p.db, p.err
+	}
+
+	conns := conf.MaxOpenConnects
+	if r.maxConns > 0 && conns <= 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("gormx: tenant %s: MaxOpenConnects is required when MaxConnections is set", tenantId)
+	}
+	evicted, ok := r.evict(conns)
+	if !ok {
+		inUse := r.conns
+		r.mu.Unlock()
+		closePools(evicted)
+		return nil, fmt.Errorf("%w: tenant %s needs %d connections, %d of %d in use", ErrTenantConnectionLimit, tenantId, conns, inUse, r.maxConns)
+	}
+	p := &tenantPool{tenantId: tenantId, conns: conns, ready: make(chan struct{})}
+	r.pools[tenantId] = r.lru.PushFront(p)
+	r.conns += conns
+	r.mu.Unlock()
+	closePools(evicted)
+
+	p.db, p.err = openTenantDB(conf)
+	if p.err != nil {
+		r.mu.Lock()
+		if e, ok := r.pools[tenantId]; ok && e.Value == p {
+			r.lru.Remove(e)
+			delete(r.pools, tenantId)
+			r.conns -= conns
+		}
+		r.mu.Unlock()
+	}
+	close(p.ready)
+	return p.db, p.err
+}
+
+// evict 在持有锁时移除最久未使用的连接池，直到可以再容纳一个需要 conns 个连接的连接池；
+// 正在创建中的连接池不会被移除。返回被移除的连接池（由调用方在释放锁后关闭）与是否有足够容量。
+func (r *TenantRouter) evict(conns int) ([]*tenantPool, bool) {
+	fits := func() bool {
+		return r.lru.Len() < r.maxPools && (r.maxConns <= 0 || r.conns+conns <= r.maxConns)
+	}
+
+	var evicted []*tenantPool
+	for e := r.lru.Back(); e != nil && !fits(); {
+		prev := e.Prev()
+		p := e.Value.(*tenantPool)
+		select {
+		case <-p.ready:
+			r.lru.Remove(e)
+			delete(r.pools, p.tenantId)
+			r.conns -= p.conns
+			evicted = append(evicted, p)
+		default:
+		}
+		e = prev
+	}
+	return evicted, fits()
+}
+
+// close 关闭连接池，正在执行的语句完成后连接才会释放。
+func (p *tenantPool) close() error {
+	if p.db == nil {
+		return nil
+	}
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// closePools 关闭被淘汰的连接池。
+func closePools(pools []*tenantPool) {
+	for _, p := range pools {
+		_ = p.close()
+	}
+}
+
+// openTenantDB 按 Conf.Type 创建租户的连接池（不执行 AutoMigrate，迁移由 TenantRouter.Migrate 统一执行）。
+func openTenantDB(conf *Conf) (*gorm.DB, error) {
+	switch conf.Type {
+	case Postgres:
+		db, err := NewPostgres(&PostgresConf{Conf: *conf}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return db.DB, nil
+	case Mysql:
+		db, err := NewMysql(&MysqlConf{Conf: *conf}, nil)
+		if err != nil {
+			return nil, err
+		}
+		return db.DB, nil
+	default:
+		return nil, fmt.Errorf("gormx: unsupported tenant database type %d", conf.Type)
+	}
+}
+
+// quoteSchema 将 schema 名转为 search_path 中的带引号标识符。
+func quoteSchema(schema string) string {
+	return `"` + strings.ReplaceAll(schema, `"`, `""`) + `"`
+}
@@ -0,0 +1,123 @@
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JSONContains 查询 JSON 列包含 value 的记录（MySQL JSON_CONTAINS，Postgres jsonb @>），
+// value 按 JSON 序列化，如 map[string]interface{}{"role": "admin"}、[]string{"go"}
+func JSONContains(column string, value interface{}) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(jsonContains{column: column, value: value})
+	}
+}
+
+// JSONExtractEq 查询 JSON 列中 path 处的值等于 value 的记录，path 以点分隔，数字段为数组下标，如 "address.city"、"tags.0"
+func JSONExtractEq(column, path string, value interface{}) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(jsonExtractEq{column: column, path: path, value: value})
+	}
+}
+
+// jsonContains 在构建 SQL 时按方言生成包含条件
+type jsonContains struct {
+	column string
+	value  interface{}
+}
+
+// Build 实现 clause.Expression
+func (c jsonContains) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(c.value)
+	if err != nil {
+		jsonError(stmt, err)
+		return
+	}
+
+	column := clause.Column{Name: c.column}
+	switch stmt.Dialector.Name() {
+	case "mysql":
+		clause.Expr{SQL: "JSON_CONTAINS(?, ?)", Vars: []interface{}{column, string(data)}}.Build(builder)
+	case "postgres":
+		clause.Expr{SQL: "? @> CAST(? AS jsonb)", Vars: []interface{}{column, string(data)}}.Build(builder)
+	default:
+		jsonError(stmt, fmt.Errorf("JSONContains is not supported by %s", stmt.Dialector.Name()))
+	}
+}
+
+// jsonExtractEq 在构建 SQL 时按方言生成路径取值的相等条件
+type jsonExtractEq struct {
+	column string
+	path   string
+	value  interface{}
+}
+
+// Build 实现 clause.Expression
+func (c jsonExtractEq) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	column := clause.Column{Name: c.column}
+	keys := strings.Split(c.path, ".")
+
+	switch stmt.Dialector.Name() {
+	case "mysql", "postgres":
+		// 两侧都按 JSON 比较，字符串、数字、布尔与对象的语义一致。
+		data, err := json.Marshal(c.value)
+		if err != nil {
+			jsonError(stmt, err)
+			return
+		}
+		if stmt.Dialector.Name() == "mysql" {
+			clause.Expr{SQL: "JSON_EXTRACT(?, ?) = CAST(? AS JSON)", Vars: []interface{}{column, jsonPath(keys), string(data)}}.Build(builder)
+		} else {
+			clause.Expr{SQL: "? #> CAST(? AS text[]) = CAST(? AS jsonb)", Vars: []interface{}{column, textArray(keys), string(data)}}.Build(builder)
+		}
+	case "sqlite":
+		clause.Expr{SQL: "json_extract(?, ?) = ?", Vars: []interface{}{column, jsonPath(keys), c.value}}.Build(builder)
+	default:
+		jsonError(stmt, fmt.Errorf("JSONExtractEq is not supported by %s", stmt.Dialector.Name()))
+	}
+}
+
+// jsonKeyEscaper 转义路径中带引号的键
+var jsonKeyEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// jsonPath 返回 MySQL/SQLite 的 JSON 路径，如 $."address"."city"、$."tags"[0]
+func jsonPath(keys []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, key := range keys {
+		if _, err := strconv.Atoi(key); err == nil {
+			b.WriteString("[" + key + "]")
+		} else {
+			b.WriteString(`."` + jsonKeyEscaper.Replace(key) + `"`)
+		}
+	}
+	return b.String()
+}
+
+// textArray 返回 Postgres 的 text[] 字面量，如 {"address","city"}
+func textArray(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = `"` + jsonKeyEscaper.Replace(key) + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// jsonError 记录构建错误，并保持 SQL 完整，出错的语句不会执行
+func jsonError(stmt *gorm.Statement, err error) {
+	_ = stmt.AddError(fmt.Errorf("scope: %w", err))
+	stmt.WriteString("1 <> 1")
+}
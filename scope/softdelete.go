@@ -0,0 +1,197 @@
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNoSoftDelete 表示模型没有软删除字段
+	ErrNoSoftDelete = errors.New("scope: model has no soft delete field")
+	// ErrRestoreConflict 表示待恢复的记录与未删除的记录（或彼此之间）唯一键冲突，可通过 errors.Is 判断
+	ErrRestoreConflict = errors.New("scope: restore conflicts with an active record")
+)
+
+// RestoreConflictError 为恢复记录时唯一键冲突的错误
+type RestoreConflictError struct {
+	// Table 为恢复的表名
+	Table string
+	// Index 为冲突的唯一索引名
+	Index string
+	// Columns 与 Values 为冲突的唯一键列及其取值
+	Columns []string
+	Values  []interface{}
+}
+
+// Error 实现 error
+func (e *RestoreConflictError) Error() string {
+	pairs := make([]string, len(e.Columns))
+	for i, column := range e.Columns {
+		pairs[i] = fmt.Sprintf("%s=%v", column, e.Values[i])
+	}
+	return fmt.Sprintf("%s: %s %s (%s)", ErrRestoreConflict, e.Table, e.Index, strings.Join(pairs, ", "))
+}
+
+// Unwrap 使 errors.Is(err, ErrRestoreConflict) 成立
+func (e *RestoreConflictError) Unwrap() error {
+	return ErrRestoreConflict
+}
+
+// WithTrashed 查询时包含已软删除的记录
+func WithTrashed(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// OnlyTrashed 只查询已软删除的记录，支持秒、毫秒、纳秒、0/1 标记与 gorm.DeletedAt
+func OnlyTrashed(db *gorm.DB) *gorm.DB {
+	return db.Unscoped().Where(trashed{})
+}
+
+// TrashedBefore 只查询在 t 之前软删除的记录；0/1 标记模式需要通过 DeletedAtField 记录删除时间
+func TrashedBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where(trashed{before: t})
+	}
+}
+
+// trashed 在构建 SQL 时按模型的软删除字段生成条件，使 scope 不依赖具体模型
+type trashed struct {
+	before time.Time
+}
+
+// Build 实现 clause.Expression
+func (t trashed) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	sd, ok := internal.ParseSoftDelete(stmt.Schema)
+	if !ok {
+		_ = stmt.AddError(fmt.Errorf("%w: %s", ErrNoSoftDelete, stmt.Table))
+		// 保持 SQL 完整，出错的语句不会执行。
+		builder.WriteString("1 <> 1")
+		return
+	}
+
+	expr := sd.Trashed()
+	if !t.before.IsZero() {
+		var err error
+		if expr, err = sd.DeletedBefore(t.before); err != nil {
+			_ = stmt.AddError(fmt.Errorf("scope: %s: %w", stmt.Table, err))
+			builder.WriteString("1 <> 1")
+			return
+		}
+	}
+	expr.Build(builder)
+}
+
+// Restore 恢复 model 中（按主键或 conds 条件）匹配的已软删除记录，返回恢复的行数。
+// 恢复前按模型的唯一约束检查冲突：与未删除记录或彼此之间唯一键重复时整体不恢复，返回 *RestoreConflictError；
+// 软删除字段、DeletedAtField 与 gormx:"deleted_by" 字段同时清空。没有任何条件时与 gorm 一致返回 ErrMissingWhereClause
+func Restore(db *gorm.DB, model interface{}, conds ...interface{}) (int64, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+	sd, ok := internal.ParseSoftDelete(stmt.Schema)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrNoSoftDelete, stmt.Table)
+	}
+	s := stmt.Schema
+
+	// 条件：db 上已有的条件、模型上非零的主键与 conds。
+	where := func(tx *gorm.DB) *gorm.DB {
+		if row := reflect.Indirect(reflect.ValueOf(model)); row.Kind() == reflect.Struct {
+			for _, field := range s.PrimaryFields {
+				if value, zero := field.ValueOf(db.Statement.Context, row); !zero {
+					tx = tx.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: value})
+				}
+			}
+		}
+		if len(conds) > 0 {
+			tx = tx.Where(conds[0], conds[1:]...)
+		}
+		return tx
+	}
+	if _, ok := where(db.Session(&gorm.Session{})).Statement.Clauses["WHERE"]; !ok && !db.AllowGlobalUpdate {
+		return 0, gorm.ErrMissingWhereClause
+	}
+
+	keys := internal.UniqueKeys(s, db.NamingStrategy)
+	columns := make([]string, 0, len(s.PrimaryFieldDBNames))
+	columns = append(columns, s.PrimaryFieldDBNames...)
+	for _, key := range keys {
+		for _, column := range key.Columns {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	var restored int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var rows []map[string]interface{}
+		if err := where(tx.Model(model).Scopes(OnlyTrashed).Select(columns)).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		// 冲突检查与恢复只按唯一键与主键匹配，不再带 db 上已有的条件。
+		tx = tx.Session(&gorm.Session{NewDB: true})
+
+		for _, key := range keys {
+			batch := make(map[string]bool, len(rows))
+			for _, row := range rows {
+				values := make([]interface{}, len(key.Columns))
+				eqs := make([]clause.Expression, len(key.Columns))
+				for i, column := range key.Columns {
+					values[i] = row[column]
+					eqs[i] = clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: row[column]}
+				}
+				// 含 NULL 的唯一键不会冲突。
+				if slices.ContainsFunc(values, func(v interface{}) bool { return v == nil }) {
+					continue
+				}
+				conflict := &RestoreConflictError{Table: s.Table, Index: key.Name, Columns: key.Columns, Values: values}
+
+				id := fmt.Sprintf("%#v", values)
+				if batch[id] {
+					return conflict
+				}
+				batch[id] = true
+
+				var count int64
+				if err := tx.Model(model).Where(clause.And(eqs...)).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					return conflict
+				}
+			}
+		}
+
+		values := sd.RestoreValues()
+		if deletedBy := internal.ParseAuditFields(s).DeletedBy; deletedBy != nil {
+			values[deletedBy.DBName] = ""
+		}
+		result := tx.Model(model).Unscoped().Where(internal.PrimaryKeys(s.PrimaryFieldDBNames, rows)).Updates(values)
+		restored = result.RowsAffected
+		return result.Error
+	})
+	return restored, err
+}
+
+// ForceDelete 物理删除 model 中（按主键或 conds 条件）匹配的记录，包括已软删除的记录，返回删除的行数
+func ForceDelete(db *gorm.DB, model interface{}, conds ...interface{}) (int64, error) {
+	result := db.Unscoped().Delete(model, conds...)
+	return result.RowsAffected, result.Error
+}
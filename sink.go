@@ -0,0 +1,59 @@
+package gormx
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	loger "gorm.io/gorm/logger"
+)
+
+// Sink 为操作日志输出目标，可通过 Conf.WithSinks 同时挂载多个，各自按级别过滤。
+type Sink = internal.Sink
+
+// OperationLogger 为交给 Sink 的结构化操作日志。
+type OperationLogger = internal.OperationLogger
+
+// TableSinkConf 为数据库表 Sink 的配置。
+type TableSinkConf = internal.TableSinkConfig
+
+// TableSink 为异步批量写入数据库表的 Sink，使用完毕需调用 Close 刷新缓冲区。
+type TableSink = internal.TableSink
+
+// NewOTelSink 构造上报到全局 OTel LoggerProvider 的 Sink（未配置 Sink 时的默认行为）。
+func NewOTelSink(level loger.LogLevel) Sink {
+	return internal.NewOTelSink(level)
+}
+
+// NewSlogSink 构造输出到 slog.Handler 的 Sink。
+func NewSlogSink(handler slog.Handler, level loger.LogLevel) Sink {
+	return internal.NewSlogSink(handler, level)
+}
+
+// NewJSONSink 构造以 JSON Lines 写入 w 的 Sink。
+func NewJSONSink(w io.Writer, level loger.LogLevel) Sink {
+	return internal.NewJSONSink(w, level)
+}
+
+// NewTableSink 构造将操作日志异步写入 db 中指定表的 Sink。
+func NewTableSink(db *gorm.DB, conf TableSinkConf) (*TableSink, error) {
+	return internal.NewTableSink(db, conf)
+}
+
+// InspectorConf 为进程内查询观测器的配置。
+type InspectorConf = internal.InspectorConfig
+
+// Inspector 为进程内查询观测器，同时是 Sink 与 http.Handler。
+type Inspector = internal.Inspector
+
+// InspectorEntry 为观测器记录的最近语句。
+type InspectorEntry = internal.InspectorEntry
+
+// FingerprintStats 为观测器按 SQL 指纹聚合的统计。
+type FingerprintStats = internal.FingerprintStats
+
+// NewInspector 构造进程内查询观测器，需通过 Conf.WithSinks 挂载后才会收到日志。
+func NewInspector(conf InspectorConf) *Inspector {
+	return internal.NewInspector(conf)
+}
@@ -0,0 +1,90 @@
+package gormx
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/fireflycore/gormx/internal"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// SqlCommenterConf 为 sqlcommenter 的配置，启用后每条 SQL 末尾追加 /*key='value',...*/ 注释。
+type SqlCommenterConf struct {
+	// 应用名（为空时使用 MetadataExtractor 提取的 AppId）
+	// Application 写入 application 字段。
+	Application string `json:"application"`
+	// 是否不写入调用方位置
+	// DisableCaller 为 true 时不写入 caller 字段。
+	DisableCaller bool `json:"disable_caller"`
+}
+
+// errSqlCommenterPrepareStmt 表示 sqlcommenter 与预处理语句缓存不能同时开启。
+var errSqlCommenterPrepareStmt = errors.New("gormx: sql commenter cannot be combined with PrepareStmt")
+
+// newSqlCommenter 构造追加 sqlcommenter 注释的 queryRewriter。
+func newSqlCommenter(c SqlCommenterConf, extractor MetadataExtractor) queryRewriter {
+	return func(ctx context.Context, query string) string {
+		if ctx == nil {
+			return query
+		}
+
+		tags := make(map[string]string, 4)
+		if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+			tags["traceparent"] = "00-" + spanCtx.TraceID().String() + "-" + spanCtx.SpanID().String() + "-" + spanCtx.TraceFlags().String()
+		}
+		if c.Application != "" {
+			tags["application"] = c.Application
+		} else if appId := extractor.Extract(ctx).AppId; appId != "" {
+			tags["application"] = appId
+		}
+		if !c.DisableCaller {
+			if caller := internal.Caller(); caller != "" {
+				tags["caller"] = caller
+			}
+		}
+		if method, ok := grpc.Method(ctx); ok {
+			tags["grpc_method"] = method
+		}
+
+		return appendSqlComment(query, tags)
+	}
+}
+
+// appendSqlComment 按 sqlcommenter 规范（key 排序、URL 编码、单引号包裹）在 SQL 末尾追加注释。
+func appendSqlComment(query string, tags map[string]string) string {
+	if len(tags) == 0 || strings.HasSuffix(strings.TrimSpace(query), "*/") {
+		return query
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.Grow(len(query) + 128)
+	b.WriteString(query)
+	b.WriteString(" /*")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(sqlCommentEscape(k))
+		b.WriteString("='")
+		b.WriteString(sqlCommentEscape(tags[k]))
+		b.WriteByte('\'')
+	}
+	b.WriteString("*/")
+	return b.String()
+}
+
+// sqlCommentEscape 对 key/value 做 URL 编码（空格编码为 %20），并转义单引号。
+func sqlCommentEscape(s string) string {
+	s = strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	return strings.ReplaceAll(s, "'", "\\'")
+}
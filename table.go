@@ -3,6 +3,8 @@ package gormx
 import (
 	"time"
 
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"gorm.io/plugin/soft_delete"
 )
 
@@ -14,10 +16,85 @@ type Table struct {
 	DeletedAt soft_delete.DeletedAt `json:"deleted_at" gorm:"default:0;index"`
 }
 
-// TableUUID 为 string 主键基类（使用 UUIDv7，数据库自动生成）。
+// TableUUID 为 string 主键基类（Postgres 原生 uuid 列，创建时由应用生成 UUIDv7，兼容 Postgres 18 以下版本）。
+// MySQL 请使用 TableBinaryUUID。
 type TableUUID struct {
-	Id        string                `json:"id" gorm:"type:uuid;primaryKey;default:uuidv7()"`
+	Id        string                `json:"id" gorm:"type:uuid;primaryKey"`
 	CreatedAt time.Time             `json:"created_at"`
 	UpdatedAt time.Time             `json:"updated_at"`
 	DeletedAt soft_delete.DeletedAt `json:"deleted_at" gorm:"default:0;index"`
 }
+
+// BeforeCreate 在 Id 为空时生成 UUIDv7，随机源不可用时返回错误（模型自定义 BeforeCreate 时需显式调用）。
+func (t *TableUUID) BeforeCreate(*gorm.DB) error {
+	if t.Id != "" {
+		return nil
+	}
+	id, err := UUIDv7Generator{}.Generate()
+	if err != nil {
+		return err
+	}
+	t.Id = id.(string)
+	return nil
+}
+
+// TableBinaryUUID 为可跨数据库的 UUID 主键基类：Postgres 为原生 uuid，MySQL 为 binary(16)，创建时由应用生成 UUIDv7。
+type TableBinaryUUID struct {
+	Id        UUID                  `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	DeletedAt soft_delete.DeletedAt `json:"deleted_at" gorm:"default:0;index"`
+}
+
+// BeforeCreate 在 Id 为零值时生成 UUIDv7，随机源不可用时返回错误（模型自定义 BeforeCreate 时需显式调用）。
+func (t *TableBinaryUUID) BeforeCreate(*gorm.DB) error {
+	if !t.Id.IsZero() {
+		return nil
+	}
+	u, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+	t.Id = UUID(u)
+	return nil
+}
+
+// TableSnowflake 为 int64 主键基类（不使用数据库自增，创建时由 Snowflake 生成按时间有序的 ID），
+// 需通过 Conf.WithIDGenerator("snowflake", gen) 注册生成器。
+type TableSnowflake struct {
+	Id        int64                 `json:"id,string" gorm:"primaryKey;autoIncrement:false" gormx:"id:snowflake"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	DeletedAt soft_delete.DeletedAt `json:"deleted_at" gorm:"default:0;index"`
+}
+
+// TableUnique 为带唯一索引支持的 uint64 主键基类：DeletedAt 加入 idx_unique 复合唯一索引，
+// 业务字段使用 gorm:"uniqueIndex:idx_unique" 即可组成 (业务键, deleted_at) 唯一约束，软删除后可重新创建相同业务键。
+// Postgres 的索引名在 schema 内全局唯一，多表场景请改用 gormx:"unique" 标签（见 MigrateUniqueIndexes）。
+type TableUnique struct {
+	Id        uint64                `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	DeletedAt soft_delete.DeletedAt `json:"deleted_at" gorm:"default:0;uniqueIndex:idx_unique"`
+}
+
+// TableUUIDUnique 为带唯一索引支持的 string 主键基类（创建时由应用生成 UUIDv7），用法同 TableUnique。
+type TableUUIDUnique struct {
+	Id        string                `json:"id" gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	DeletedAt soft_delete.DeletedAt `json:"deleted_at" gorm:"default:0;uniqueIndex:idx_unique"`
+}
+
+// BeforeCreate 在 Id 为空时生成 UUIDv7，随机源不可用时返回错误（模型自定义 BeforeCreate 时需显式调用）。
+func (t *TableUUIDUnique) BeforeCreate(*gorm.DB) error {
+	if t.Id != "" {
+		return nil
+	}
+	id, err := UUIDv7Generator{}.Generate()
+	if err != nil {
+		return err
+	}
+	t.Id = id.(string)
+	return nil
+}
@@ -0,0 +1,240 @@
+package gormx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrMissingTenant 表示对租户模型的操作没有租户上下文，可通过 errors.Is 判断。
+	ErrMissingTenant = errors.New("gormx: missing tenant in context")
+	// ErrTenantMismatch 表示创建的记录已赋值的 TenantId 与上下文中的租户不一致。
+	ErrTenantMismatch = errors.New("gormx: tenant mismatch")
+)
+
+// Tenant 为租户字段，对带该字段的模型，gormx 按请求上下文中的租户 ID 自动过滤与填充。
+type Tenant struct {
+	TenantId string `json:"tenant_id" gorm:"size:64;not null;index" gormx:"tenant"`
+}
+
+// TenantTable 为带租户字段的 uint64 主键基类（行级多租户）。
+type TenantTable struct {
+	Table
+	Tenant
+}
+
+// skipTenantKey 为跳过租户过滤标记在 context 中的 key。
+type skipTenantKey struct{}
+
+// SkipTenant 返回跳过租户过滤的 context，用于跨租户的运维任务与后台统计，需配合 db.WithContext(ctx) 使用。
+func SkipTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipTenantKey{}, true)
+}
+
+// tenantKey 为 WithTenant 写入的租户 ID 在 context 中的 key。
+type tenantKey struct{}
+
+// WithTenant 为 context 指定租户 ID，优先于 MetadataExtractor 提取的值，适用于后台任务。
+func WithTenant(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantId)
+}
+
+// isSkipTenant 判断 context 是否通过 SkipTenant 跳过租户过滤。
+func isSkipTenant(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipTenantKey{}).(bool)
+	return skip
+}
+
+// tenantFromContext 返回 WithTenant 指定的租户 ID，未指定时使用 MetadataExtractor 提取的值。
+func tenantFromContext(ctx context.Context, extractor MetadataExtractor) string {
+	if tenantId, _ := ctx.Value(tenantKey{}).(string); tenantId != "" {
+		return tenantId
+	}
+	return extractor.Extract(ctx).TenantId
+}
+
+// tenantPlugin 为租户模型的查询、更新、删除追加 tenant_id = ? 条件，并在创建时填充租户 ID。
+type tenantPlugin struct {
+	extractor MetadataExtractor
+	// fields 缓存每个模型的租户字段（没有时为 nil），key 为 *schema.Schema。
+	fields sync.Map
+}
+
+// newTenantPlugin 构造租户插件，租户 ID 与日志使用同一个 MetadataExtractor。
+func newTenantPlugin(c *Conf) *tenantPlugin {
+	return &tenantPlugin{extractor: c.metadataExtractor()}
+}
+
+// Name 实现 gorm.Plugin。
+func (p *tenantPlugin) Name() string {
+	return "gormx:tenant"
+}
+
+// Initialize 实现 gorm.Plugin。
+func (p *tenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register(p.Name(), p.beforeCreate),
+		cb.Query().Before("gorm:query").Register(p.Name(), p.beforeQuery),
+		cb.Row().Before("gorm:row").Register(p.Name(), p.beforeQuery),
+		cb.Update().Before("gorm:update").Register(p.Name(), p.beforeUpdate),
+		cb.Delete().Before("gorm:delete").Register(p.Name(), p.beforeDelete),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tenant 返回模型的租户字段与当前请求的租户 ID，无需处理时 ok 为 false；
+// 租户模型缺少租户上下文（且未 SkipTenant）时写入 ErrMissingTenant。
+func (p *tenantPlugin) tenant(db *gorm.DB) (*schema.Field, string, bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return nil, "", false
+	}
+	field := p.tenantField(stmt.Schema)
+	if field == nil {
+		return nil, "", false
+	}
+
+	ctx := stmt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if isSkipTenant(ctx) {
+		return nil, "", false
+	}
+	tenantId := tenantFromContext(ctx, p.extractor)
+	if tenantId == "" {
+		_ = db.AddError(fmt.Errorf("%w: %s", ErrMissingTenant, stmt.Table))
+		return nil, "", false
+	}
+	return field, tenantId, true
+}
+
+// beforeQuery 为查询追加租户条件。
+func (p *tenantPlugin) beforeQuery(db *gorm.DB) {
+	if field, tenantId, ok := p.tenant(db); ok {
+		p.where(db.Statement, field, tenantId)
+	}
+}
+
+// beforeUpdate 为更新追加租户条件，并禁止通过更新修改 TenantId。
+func (p *tenantPlugin) beforeUpdate(db *gorm.DB) {
+	field, tenantId, ok := p.tenant(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	stmt.Omits = append(stmt.Omits, field.DBName)
+	// 没有任何条件时不追加，保留 gorm 的 ErrMissingWhereClause 检查。
+	if stmt.AllowGlobalUpdate || internal.HasConditions(stmt) {
+		p.where(stmt, field, tenantId)
+	}
+}
+
+// beforeDelete 为删除（含软删除）追加租户条件。
+func (p *tenantPlugin) beforeDelete(db *gorm.DB) {
+	field, tenantId, ok := p.tenant(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	if stmt.AllowGlobalUpdate || internal.HasConditions(stmt) {
+		p.where(stmt, field, tenantId)
+	}
+}
+
+// where 以 AND 追加 tenant_id = ? 条件。
+func (p *tenantPlugin) where(stmt *gorm.Statement, field *schema.Field, tenantId string) {
+	internal.AddWhere(stmt, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantId})
+}
+
+// beforeCreate 为每条待创建记录填充租户 ID，已赋值且与上下文不一致时返回 ErrTenantMismatch。
+func (p *tenantPlugin) beforeCreate(db *gorm.DB) {
+	field, tenantId, ok := p.tenant(db)
+	if !ok {
+		return
+	}
+
+	stmt := db.Statement
+	check := func(value interface{}) bool {
+		if v, ok := value.(string); ok && v != "" && v != tenantId {
+			_ = db.AddError(fmt.Errorf("%w: %s has tenant %q, context tenant %q", ErrTenantMismatch, stmt.Table, v, tenantId))
+			return false
+		}
+		return true
+	}
+	fillMap := func(m map[string]interface{}) {
+		if value, ok := m[field.DBName]; ok && !check(value) {
+			return
+		}
+		if value, ok := m[field.Name]; ok {
+			if !check(value) {
+				return
+			}
+			delete(m, field.Name)
+		}
+		m[field.DBName] = tenantId
+	}
+	fill := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct {
+			return
+		}
+		value, zero := field.ValueOf(stmt.Context, row)
+		if !zero {
+			check(value)
+			return
+		}
+		_ = field.Set(stmt.Context, row, tenantId)
+	}
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		fillMap(dest)
+		return
+	case *map[string]interface{}:
+		fillMap(*dest)
+		return
+	case []map[string]interface{}:
+		for _, m := range dest {
+			fillMap(m)
+		}
+		return
+	case *[]map[string]interface{}:
+		for _, m := range *dest {
+			fillMap(m)
+		}
+		return
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			fill(stmt.ReflectValue.Index(i))
+		}
+	case reflect.Struct:
+		fill(stmt.ReflectValue)
+	}
+}
+
+// tenantField 返回并缓存模型的租户字段。
+func (p *tenantPlugin) tenantField(s *schema.Schema) *schema.Field {
+	if v, ok := p.fields.Load(s); ok {
+		return v.(*schema.Field)
+	}
+	field := internal.TenantField(s)
+	p.fields.Store(s, field)
+	return field
+}
@@ -0,0 +1,145 @@
+package gormx
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingConf 为 otelgorm 链路追踪的配置，零值即默认行为（开启追踪，记录完整 SQL）。
+type TracingConf struct {
+	// 是否关闭链路追踪
+	// Disable 为 true 时不挂载 otelgorm 插件。
+	Disable bool `json:"disable"`
+	// 是否在 Span 中去除 SQL 文本（合规场景使用）
+	// ExcludeQuery 为 true 时 Span 不含 db.statement 属性；只影响 Span，日志中的 SQL 不受影响。
+	ExcludeQuery bool `json:"exclude_query"`
+	// 是否在 Span 中去除 SQL 参数
+	// ExcludeQueryVars 为 true 时 SQL 参数以 ? 代替。
+	ExcludeQueryVars bool `json:"exclude_query_vars"`
+	// 附加到每个 Span 的静态属性（如 service、shard、role）
+	// Attributes 以字符串属性写入 Span。
+	Attributes map[string]string `json:"attributes"`
+	// SELECT 的 Span 采样率（0-1，0 或 >=1 表示全部记录）
+	// SelectSampleRate 作用于 Find/First/Row 等查询，不影响写操作。
+	SelectSampleRate float64 `json:"select_sample_rate"`
+}
+
+// WithTracerProvider 设置链路追踪使用的 TracerProvider（默认使用全局 TracerProvider）。
+func (c *Conf) WithTracerProvider(provider trace.TracerProvider) {
+	c.tracerProvider = provider
+}
+
+// tracingOptions 根据配置构造 otelgorm 选项。
+func (c *Conf) tracingOptions() []otelgorm.Option {
+	opts := []otelgorm.Option{otelgorm.WithDBName(c.Database)}
+
+	if c.Tracing.ExcludeQueryVars {
+		opts = append(opts, otelgorm.WithoutQueryVariables())
+	}
+	if len(c.Tracing.Attributes) != 0 {
+		attrs := make([]attribute.KeyValue, 0, len(c.Tracing.Attributes))
+		for k, v := range c.Tracing.Attributes {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+		opts = append(opts, otelgorm.WithAttributes(attrs...))
+	}
+
+	provider := c.tracerProvider
+	if rate := c.Tracing.SelectSampleRate; rate > 0 && rate < 1 {
+		// 未注入 TracerProvider 时包装全局 TracerProvider（全局 Provider 晚注册时同样生效）。
+		if provider == nil {
+			provider = otel.GetTracerProvider()
+		}
+		provider = &selectSamplingProvider{TracerProvider: provider, rate: rate}
+	}
+	if c.Tracing.ExcludeQuery {
+		// otelgorm 总会写入 db.statement，只能在 Span 上过滤。
+		if provider == nil {
+			provider = otel.GetTracerProvider()
+		}
+		provider = &excludeQueryProvider{TracerProvider: provider}
+	}
+	if provider != nil {
+		opts = append(opts, otelgorm.WithTracerProvider(provider))
+	}
+
+	return opts
+}
+
+// selectSpanNames 为 otelgorm 中 SELECT 类操作的 Span 名称。
+var selectSpanNames = map[string]bool{
+	"gorm.Query": true,
+	"gorm.Row":   true,
+}
+
+// selectSamplingProvider 对 SELECT 类 Span 按比例采样。
+type selectSamplingProvider struct {
+	trace.TracerProvider
+	rate float64
+}
+
+// Tracer 实现 trace.TracerProvider。
+func (p *selectSamplingProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &selectSamplingTracer{Tracer: p.TracerProvider.Tracer(name, opts...), rate: p.rate}
+}
+
+// selectSamplingTracer 未被采样的 SELECT 返回不记录的 Span，并保留父 Span 上下文。
+type selectSamplingTracer struct {
+	trace.Tracer
+	rate float64
+}
+
+// Start 实现 trace.Tracer。
+func (t *selectSamplingTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if selectSpanNames[spanName] && rand.Float64() >= t.rate {
+		ctx = trace.ContextWithSpanContext(ctx, trace.SpanContextFromContext(ctx))
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return t.Tracer.Start(ctx, spanName, opts...)
+}
+
+// dbStatementKey 为 otelgorm 写入 SQL 文本的属性。
+const dbStatementKey = attribute.Key("db.statement")
+
+// excludeQueryProvider 返回的 Span 丢弃 db.statement 属性。
+type excludeQueryProvider struct {
+	trace.TracerProvider
+}
+
+// Tracer 实现 trace.TracerProvider。
+func (p *excludeQueryProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return &excludeQueryTracer{Tracer: p.TracerProvider.Tracer(name, opts...)}
+}
+
+// excludeQueryTracer 将 Span 包装为 excludeQuerySpan 并写回 context（otelgorm 从 context 取回 Span）。
+type excludeQueryTracer struct {
+	trace.Tracer
+}
+
+// Start 实现 trace.Tracer。
+func (t *excludeQueryTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx, span := t.Tracer.Start(ctx, spanName, opts...)
+	span = excludeQuerySpan{Span: span}
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// excludeQuerySpan 设置属性时去除 db.statement。
+type excludeQuerySpan struct {
+	trace.Span
+}
+
+// SetAttributes 实现 trace.Span。
+func (s excludeQuerySpan) SetAttributes(kv ...attribute.KeyValue) {
+	attrs := make([]attribute.KeyValue, 0, len(kv))
+	for _, attr := range kv {
+		if attr.Key != dbStatementKey {
+			attrs = append(attrs, attr)
+		}
+	}
+	s.Span.SetAttributes(attrs...)
+}
@@ -0,0 +1,81 @@
+package gormx
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingProvider 记录所有 Span 设置的属性
+type recordingProvider struct {
+	noop.TracerProvider
+
+	mu    sync.Mutex
+	attrs []attribute.KeyValue
+}
+
+func (p *recordingProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{provider: p}
+}
+
+// has 返回是否记录过 key 属性
+func (p *recordingProvider) has(key attribute.Key) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, attr := range p.attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	provider *recordingProvider
+}
+
+func (t recordingTracer) Start(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := recordingSpan{provider: t.provider}
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingSpan struct {
+	noop.Span
+	provider *recordingProvider
+}
+
+func (s recordingSpan) IsRecording() bool {
+	return true
+}
+
+func (s recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.provider.mu.Lock()
+	defer s.provider.mu.Unlock()
+	s.provider.attrs = append(s.provider.attrs, kv...)
+}
+
+func TestTracingExcludeQuery(t *testing.T) {
+	for _, exclude := range []bool{false, true} {
+		provider := &recordingProvider{}
+		db, _ := newTestDB(t, "postgres", func(c *Conf) {
+			c.Tracing = TracingConf{ExcludeQuery: exclude}
+			c.WithTracerProvider(provider)
+		})
+
+		var users []secretUser
+		if err := db.Find(&users).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !provider.has("db.sql.table") {
+			t.Fatal("span attributes not recorded")
+		}
+		if provider.has(dbStatementKey) == exclude {
+			t.Fatalf("exclude query %v: db.statement recorded = %v", exclude, !exclude)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gormx
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	loger "gorm.io/gorm/logger"
+)
+
+// defaultSlowTransaction 为默认的慢事务阈值。
+const defaultSlowTransaction = time.Second
+
+// TransactionConf 为事务级追踪的配置。
+type TransactionConf struct {
+	// 慢事务阈值（单位：毫秒，默认1000），事务从 Begin 到 Commit/Rollback 超过该时长时输出 warn 日志
+	// SlowThreshold 以事务总耗时计算，即使事务内每条语句都很快。
+	SlowThreshold int `json:"slow_threshold"`
+	// 是否输出 begin/commit/rollback 事件（info 级别）
+	// LogEvents 为 false 时只输出慢事务。
+	LogEvents bool `json:"log_events"`
+}
+
+// txTracer 记录事务的 begin/commit/rollback、总耗时与语句条数，并为事务创建 Span。
+type txTracer struct {
+	logger loger.Interface
+	// tracer 为空表示关闭了链路追踪，只记录日志。
+	tracer    trace.Tracer
+	attrs     []attribute.KeyValue
+	slow      time.Duration
+	logEvents bool
+}
+
+// useTransactionTracer 为 db 挂载事务级追踪。
+func useTransactionTracer(db *gorm.DB, c *Conf) error {
+	t := &txTracer{
+		logger:    db.Logger,
+		slow:      time.Millisecond * time.Duration(c.Transaction.SlowThreshold),
+		logEvents: c.Transaction.LogEvents,
+	}
+	if t.slow <= 0 {
+		t.slow = defaultSlowTransaction
+	}
+
+	if !c.Tracing.Disable {
+		provider := c.tracerProvider
+		if provider == nil {
+			provider = otel.GetTracerProvider()
+		}
+		t.tracer = provider.Tracer("github.com/fireflycore/gormx")
+		t.attrs = append(t.attrs, attribute.String("db.system", db.Dialector.Name()), attribute.String("db.name", c.Database))
+		for k, v := range c.Tracing.Attributes {
+			t.attrs = append(t.attrs, attribute.String(k, v))
+		}
+
+		// 事务内语句的 Span 挂到事务 Span 下，需在 otelgorm 创建 Span 之前执行。
+		cb := db.Callback()
+		for _, err := range []error{
+			cb.Create().Before("*").Register("gormx:transaction", t.inject),
+			cb.Query().Before("*").Register("gormx:transaction", t.inject),
+			cb.Update().Before("*").Register("gormx:transaction", t.inject),
+			cb.Delete().Before("*").Register("gormx:transaction", t.inject),
+			cb.Row().Before("*").Register("gormx:transaction", t.inject),
+			cb.Raw().Before("*").Register("gormx:transaction", t.inject),
+		} {
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	pool := wrapConnPool(db)
+	pool.observers = append(pool.observers, t.observe)
+
+	return nil
+}
+
+// observe 实现 txObserver，在 Begin 时开启事务 Span，结束时记录耗时与语句条数。
+func (t *txTracer) observe(tx *connTx) func(committed bool) {
+	start := time.Now()
+	ctx := tx.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if t.tracer != nil {
+		ctx, tx.span = t.tracer.Start(ctx, "gorm.Transaction",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithTimestamp(start),
+			trace.WithAttributes(t.attrs...),
+		)
+	}
+	if t.logEvents {
+		t.logger.Info(ctx, "transaction begin")
+	}
+
+	return func(committed bool) {
+		elapsed := time.Since(start)
+		statements := tx.statements.Load()
+
+		outcome := "rollback"
+		if committed {
+			outcome = "commit"
+		}
+
+		if tx.span != nil {
+			tx.span.SetAttributes(
+				attribute.String("db.transaction.outcome", outcome),
+				attribute.Int64("db.transaction.statements", statements),
+			)
+			if !committed {
+				tx.span.SetStatus(codes.Error, "transaction rolled back")
+			}
+			tx.span.End()
+		}
+
+		switch {
+		case elapsed >= t.slow:
+			t.logger.Warn(ctx, "slow transaction: %s after %s, %d statements (threshold %s)",
+				outcome, elapsed.Round(time.Microsecond), statements, t.slow)
+		case t.logEvents:
+			t.logger.Info(ctx, "transaction %s after %s, %d statements", outcome, elapsed.Round(time.Microsecond), statements)
+		}
+	}
+}
+
+// inject 将事务 Span 写入事务内语句的 Statement.Context，使语句 Span 成为其子 Span。
+func (t *txTracer) inject(db *gorm.DB) {
+	conn := db.Statement.ConnPool
+	// 事务内通过 Session(PrepareStmt) 开启预处理时，connTx 被 PreparedStmtTX 包装。
+	if prepared, ok := conn.(*gorm.PreparedStmtTX); ok {
+		conn = prepared.Tx
+	}
+	tx, ok := conn.(*connTx)
+	if !ok || tx.span == nil || db.Statement.Context == nil {
+		return
+	}
+	if trace.SpanFromContext(db.Statement.Context) == tx.span {
+		return
+	}
+	db.Statement.Context = trace.ContextWithSpan(db.Statement.Context, tx.span)
+}
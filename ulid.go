@@ -0,0 +1,93 @@
+package gormx
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+)
+
+// crockford 为 ULID 使用的 Crockford Base32 字母表。
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ErrULIDOverflow 表示同一毫秒内生成的 ULID 超出了随机部分的单调递增空间。
+var ErrULIDOverflow = errors.New("gormx: ulid entropy overflow within the same millisecond")
+
+// ULIDGenerator 生成 26 位 ULID 字符串（48 位毫秒时间戳 + 80 位随机数），
+// 同一毫秒内随机部分单调递增，保证同一生成器产生的 ID 严格有序。
+type ULIDGenerator struct {
+	mu     sync.Mutex
+	lastMs uint64
+	// entropy 为上一个 ID 的 80 位随机部分。
+	entropy [10]byte
+	now     func() time.Time
+}
+
+// NewULIDGenerator 构造 ULID 生成器。
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{now: time.Now}
+}
+
+// Generate 实现 IDGenerator。
+func (g *ULIDGenerator) Generate() (interface{}, error) {
+	return g.NewULID()
+}
+
+// NewULID 生成一个 ULID。
+func (g *ULIDGenerator) NewULID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms <= g.lastMs {
+		// 同一毫秒（或时钟回拨）时沿用上次的时间戳并递增随机部分，保持单调。
+		ms = g.lastMs
+		if !incrementEntropy(&g.entropy) {
+			return "", ErrULIDOverflow
+		}
+	} else {
+		if _, err := rand.Read(g.entropy[:]); err != nil {
+			return "", err
+		}
+		g.lastMs = ms
+	}
+
+	var id [16]byte
+	id[0] = byte(ms >> 40)
+	id[1] = byte(ms >> 32)
+	id[2] = byte(ms >> 24)
+	id[3] = byte(ms >> 16)
+	id[4] = byte(ms >> 8)
+	id[5] = byte(ms)
+	copy(id[6:], g.entropy[:])
+
+	return encodeULID(id), nil
+}
+
+// incrementEntropy 将 80 位随机部分加一，溢出时返回 false。
+func incrementEntropy(entropy *[10]byte) bool {
+	for i := len(entropy) - 1; i >= 0; i-- {
+		entropy[i]++
+		if entropy[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID 将 128 位 ULID 编码为 26 位 Crockford Base32（首字符只占 3 位）。
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	// 128 位按 5 位一组从低位向高位编码，最高位补 2 个 0 凑满 130 位。
+	var hi, lo uint64
+	for i := 0; i < 8; i++ {
+		hi = hi<<8 | uint64(id[i])
+		lo = lo<<8 | uint64(id[i+8])
+	}
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
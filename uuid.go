@@ -1,12 +1,126 @@
 package gormx
 
-import "github.com/google/uuid"
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 
-// NewUUIDv7 生成一个 UUIDv7 字符串
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// NewUUIDv7 生成一个 UUIDv7 字符串，随机源不可用时 panic（与 uuid.New 一致）；需要处理错误时使用 UUIDv7Generator
 func NewUUIDv7() string {
-	u, e := uuid.NewV7()
-	if e != nil {
-		return NewUUIDv7()
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// UUID 为可跨数据库存储的 UUID：Postgres 使用原生 uuid，MySQL 使用紧凑的 binary(16)，其余数据库使用 char(36)。
+// JSON 与 String 均为标准的 36 位字符串形式。
+type UUID [16]byte
+
+// NewUUID 生成一个 UUIDv7，随机源不可用时 panic（与 uuid.New 一致）
+func NewUUID() UUID {
+	return UUID(uuid.Must(uuid.NewV7()))
+}
+
+// ParseUUID 解析 36 位（或 32 位无连字符）字符串形式的 UUID
+func ParseUUID(s string) (UUID, error) {
+	u, err := uuid.Parse(s)
+	if err != nil {
+		return UUID{}, err
+	}
+	return UUID(u), nil
+}
+
+// String 返回标准的 36 位字符串形式
+func (u UUID) String() string {
+	return uuid.UUID(u).String()
+}
+
+// IsZero 判断是否为零值
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// Scan 实现 sql.Scanner，兼容 binary(16) 与字符串形式
+func (u *UUID) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*u = UUID{}
+		return nil
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.parse(string(v))
+	case string:
+		return u.parse(v)
+	case [16]byte:
+		*u = v
+		return nil
+	default:
+		return fmt.Errorf("gormx: cannot scan %T into UUID", value)
+	}
+}
+
+// parse 解析字符串并写入 u
+func (u *UUID) parse(s string) error {
+	parsed, err := ParseUUID(s)
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// Value 实现 driver.Valuer，返回字符串形式（MySQL 的二进制形式由 GormValue 处理）
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface
+func (UUID) GormDataType() string {
+	return "uuid"
+}
+
+// GormDBDataType 按方言返回列类型
+func (UUID) GormDBDataType(db *gorm.DB, _ *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return "uuid"
+	case "mysql":
+		return "binary(16)"
+	default:
+		return "char(36)"
+	}
+}
+
+// GormValue 实现 gorm.Valuer：MySQL 写入 16 字节二进制，其余数据库写入字符串
+func (u UUID) GormValue(_ context.Context, db *gorm.DB) clause.Expr {
+	if db.Dialector.Name() == "mysql" {
+		return clause.Expr{SQL: "?", Vars: []interface{}{u[:]}}
+	}
+	return clause.Expr{SQL: "?", Vars: []interface{}{u.String()}}
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (u UUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，空字符串解析为零值
+func (u *UUID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*u = UUID{}
+		return nil
 	}
-	return u.String()
+	return u.parse(s)
 }
@@ -0,0 +1,237 @@
+package gormx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fireflycore/gormx/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleObject 表示乐观锁冲突：记录已被其它请求修改（或已不存在），可通过 errors.Is 判断。
+var ErrStaleObject = errors.New("gormx: stale object")
+
+// StaleObjectError 为乐观锁冲突时返回的错误。
+type StaleObjectError struct {
+	// Table 为更新的表名。
+	Table string
+	// Version 为更新时携带的版本号。
+	Version Version
+}
+
+// Error 实现 error。
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("%s: %s was modified concurrently (version %d)", ErrStaleObject, e.Table, e.Version)
+}
+
+// Unwrap 使 errors.Is(err, ErrStaleObject) 成立。
+func (e *StaleObjectError) Unwrap() error {
+	return ErrStaleObject
+}
+
+// Version 为乐观锁版本号：创建时为 1，每次通过 gorm 更新时追加 WHERE version = ? 并自增，
+// 影响行数为 0 时返回 *StaleObjectError。版本号为 0 的更新（如未加载的批量更新）不做校验，只自增。
+type Version int64
+
+// versionInstanceKey 为本次更新所校验的版本号在 Statement 实例中的 key。
+const versionInstanceKey = "gormx:version"
+
+// CreateClauses 实现 schema.CreateClausesInterface，创建时将零值版本号置为 1。
+func (Version) CreateClauses(field *schema.Field) []clause.Interface {
+	return []clause.Interface{versionCreateClause{field: field}}
+}
+
+// UpdateClauses 实现 schema.UpdateClausesInterface，更新时追加版本条件并自增。
+func (Version) UpdateClauses(field *schema.Field) []clause.Interface {
+	return []clause.Interface{versionUpdateClause{field: field}}
+}
+
+// ETag 返回版本号对应的强 ETag（如 "3"），用于 HTTP ETag 响应头或 gRPC metadata。
+func (v Version) ETag() string {
+	return strconv.Quote(strconv.FormatInt(int64(v), 10))
+}
+
+// Match 判断 If-Match 取值是否与当前版本一致，支持逗号分隔的多个 ETag、弱 ETag（W/"3"）与 *。
+func (v Version) Match(ifMatch string) bool {
+	for _, etag := range strings.Split(ifMatch, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "*" {
+			return true
+		}
+		if parsed, err := ParseETag(etag); err == nil && parsed == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseETag 将 ETag（"3"、W/"3" 或 3）解析为版本号，通常来自 If-Match 请求头。
+func ParseETag(etag string) (Version, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if unquoted, err := strconv.Unquote(etag); err == nil {
+		etag = unquoted
+	}
+	n, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("gormx: invalid etag %q", etag)
+	}
+	return Version(n), nil
+}
+
+// TableVersion 为带乐观锁的 uint64 主键基类。
+type TableVersion struct {
+	Table
+	Version Version `json:"version" gorm:"not null;default:1"`
+}
+
+// versionCreateClause 在创建时为零值版本号赋值 1。
+type versionCreateClause struct {
+	field *schema.Field
+}
+
+// Name 实现 clause.Interface。
+func (versionCreateClause) Name() string {
+	return ""
+}
+
+// Build 实现 clause.Interface。
+func (versionCreateClause) Build(clause.Builder) {}
+
+// MergeClause 实现 clause.Interface。
+func (versionCreateClause) MergeClause(*clause.Clause) {}
+
+// ModifyStatement 实现 gorm.StatementModifier。
+func (c versionCreateClause) ModifyStatement(stmt *gorm.Statement) {
+	set := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := c.field.ValueOf(stmt.Context, row); zero {
+			_ = c.field.Set(stmt.Context, row, 1)
+		}
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			set(stmt.ReflectValue.Index(i))
+		}
+	case reflect.Struct:
+		set(stmt.ReflectValue)
+	}
+}
+
+// versionUpdateClause 在更新时追加 WHERE version = ? 并将版本号自增。
+type versionUpdateClause struct {
+	field *schema.Field
+}
+
+// Name 实现 clause.Interface。
+func (versionUpdateClause) Name() string {
+	return ""
+}
+
+// Build 实现 clause.Interface。
+func (versionUpdateClause) Build(clause.Builder) {}
+
+// MergeClause 实现 clause.Interface。
+func (versionUpdateClause) MergeClause(*clause.Clause) {}
+
+// destSchemaCache 为更新目标结构体的 schema 缓存。
+var destSchemaCache sync.Map
+
+// ModifyStatement 实现 gorm.StatementModifier。
+func (c versionUpdateClause) ModifyStatement(stmt *gorm.Statement) {
+	if _, ok := stmt.InstanceGet(versionInstanceKey); ok {
+		return
+	}
+
+	var version Version
+	if !stmt.Unscoped && stmt.ReflectValue.Kind() == reflect.Struct && internal.HasConditions(stmt) {
+		if value, zero := c.field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			if v, ok := value.(Version); ok {
+				version = v
+				internal.AddWhere(stmt, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: c.field.DBName}, Value: int64(version)})
+			}
+		}
+	}
+
+	// 结构体无法写入 version + 1 表达式，先转换为 map（与 Select/Omit 规则一致）。
+	if dest := reflect.Indirect(reflect.ValueOf(stmt.Dest)); dest.Kind() == reflect.Struct {
+		destSchema, err := schema.Parse(stmt.Dest, &destSchemaCache, stmt.DB.NamingStrategy)
+		if err != nil {
+			_ = stmt.AddError(err)
+			return
+		}
+		selectColumns, restricted := stmt.SelectAndOmitColumns(false, true)
+		values := make(map[string]interface{}, len(destSchema.Fields))
+		for _, field := range destSchema.Fields {
+			if field.DBName == "" || field.DBName == c.field.DBName || field.PrimaryKey || !field.Updatable || field.AutoUpdateTime > 0 {
+				continue
+			}
+			if v, ok := selectColumns[field.DBName]; (ok && v) || (!ok && !restricted) {
+				value, zero := field.ValueOf(stmt.Context, dest)
+				if ok || !zero {
+					values[field.DBName] = value
+				}
+			}
+		}
+		stmt.Dest = values
+	}
+
+	stmt.SetColumn(c.field.DBName, clause.Expr{SQL: stmt.Quote(c.field.DBName) + "+1"}, true)
+	// 显式 Select 部分列时同样需要选中版本列。
+	if len(stmt.Selects) > 0 {
+		stmt.Selects = append(stmt.Selects, c.field.DBName)
+	}
+	stmt.InstanceSet(versionInstanceKey, version)
+}
+
+// versionPlugin 在更新后检查乐观锁冲突，并同步内存中的版本号。
+type versionPlugin struct{}
+
+// Name 实现 gorm.Plugin。
+func (versionPlugin) Name() string {
+	return "gormx:version"
+}
+
+// Initialize 实现 gorm.Plugin。
+func (p versionPlugin) Initialize(db *gorm.DB) error {
+	return db.Callback().Update().After("gorm:update").Register(p.Name(), p.afterUpdate)
+}
+
+// afterUpdate 携带版本条件但影响 0 行时返回 *StaleObjectError，成功时将模型的版本号加一。
+func (versionPlugin) afterUpdate(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || db.DryRun {
+		return
+	}
+	value, ok := stmt.InstanceGet(versionInstanceKey)
+	if !ok {
+		return
+	}
+	version, _ := value.(Version)
+	if version == 0 {
+		return
+	}
+
+	if db.RowsAffected == 0 {
+		_ = db.AddError(&StaleObjectError{Table: stmt.Table, Version: version})
+		return
+	}
+
+	if stmt.ReflectValue.Kind() == reflect.Struct && stmt.ReflectValue.CanAddr() {
+		for _, field := range stmt.Schema.Fields {
+			if _, ok := reflect.New(field.IndirectFieldType).Interface().(*Version); ok {
+				_ = field.Set(stmt.Context, stmt.ReflectValue, version+1)
+			}
+		}
+	}
+}